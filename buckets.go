@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Bucketed mode options, set with --bucket and --table
+var (
+	bucketPeriod = "" // Period to count commits per; empty when not bucketing
+	bucketTable  = false
+)
+
+// bucketRow is one author's row of the authors × periods matrix
+type bucketRow struct {
+	author string // "Name <email>", as in git shortlog -e
+	counts []int
+	total  int
+}
+
+// runBucketTable prints the authors × periods matrix, instead of starting the UI
+func runBucketTable() {
+	commits, err := readHistory("", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		exit(1)
+	}
+	requireCommits(len(commits))
+	labels, rows := bucketMatrix(commits, bucketPeriod)
+	writeBucketTable(os.Stdout, labels, rows)
+}
+
+// bucketMatrix counts commits per author and period, with authors ranked by
+// their total (like git shortlog -n), and returns the periods' labels
+func bucketMatrix(commits []historyCommit, period string) ([]string, []bucketRow) {
+	starts, counts := periodCounts(commits, period)
+	labels := make([]string, len(starts))
+	for i, start := range starts {
+		labels[i] = periodLabel(start, period)
+	}
+
+	rows := make([]bucketRow, 0, len(counts))
+	for author, c := range counts {
+		total := 0
+		for _, n := range c {
+			total += n
+		}
+		rows = append(rows, bucketRow{author, c, total})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].total != rows[j].total {
+			return rows[i].total > rows[j].total
+		}
+		return rows[i].author < rows[j].author
+	})
+	return labels, rows
+}
+
+// writeBucketTable prints the matrix as aligned columns, with a totals row
+func writeBucketTable(w io.Writer, labels []string, rows []bucketRow) {
+	authorWidth := len("Total")
+	for _, r := range rows {
+		authorWidth = max(authorWidth, len(r.author))
+	}
+	colWidth := len("Total")
+	for _, l := range labels {
+		colWidth = max(colWidth, len(l))
+	}
+
+	fmt.Fprintf(w, "%-*s", authorWidth, "Author")
+	for _, l := range labels {
+		fmt.Fprintf(w, "  %*s", colWidth, l)
+	}
+	fmt.Fprintf(w, "  %*s\n", colWidth, "Total")
+
+	totals := make([]int, len(labels))
+	grandTotal := 0
+	for _, r := range rows {
+		fmt.Fprintf(w, "%-*s", authorWidth, r.author)
+		for i, n := range r.counts {
+			fmt.Fprintf(w, "  %*d", colWidth, n)
+			totals[i] += n
+		}
+		fmt.Fprintf(w, "  %*d\n", colWidth, r.total)
+		grandTotal += r.total
+	}
+
+	fmt.Fprintf(w, "%-*s", authorWidth, "Total")
+	for _, n := range totals {
+		fmt.Fprintf(w, "  %*d", colWidth, n)
+	}
+	fmt.Fprintf(w, "  %*d\n", colWidth, grandTotal)
+}
+
+// bucketSeries returns the per-period series section of the preview, for
+// the given authors' commits after sinceDate (empty if there are none, and
+// the error if git log failed)
+func bucketSeries(authors []string, sinceDate, period string) string {
+	logArgs := []string{"log", historyFormat}
+	for _, author := range authors {
+		logArgs = append(logArgs, "--author="+author)
+	}
+	if sinceDate != "" {
+		logArgs = append(logArgs, "--since="+sinceDate)
+	}
+	logArgs = append(logArgs, historyArgs(gitArgs)...)
+
+	out, err := gitOutput(logArgs...)
+	if err != nil {
+		return fmt.Sprintf("%s%v%s\n\n", colorRed, err, colorReset)
+	}
+	labels, rows := bucketMatrix(parseHistory(string(out)), period)
+	return formatBucketSeries(labels, rows, period)
+}
+
+// formatBucketSeries draws each author's series as one bar per period
+func formatBucketSeries(labels []string, rows []bucketRow, period string) string {
+	if len(rows) == 0 {
+		return ""
+	}
+	const barWidth = 40
+
+	peak := 1
+	for _, r := range rows {
+		for _, n := range r.counts {
+			peak = max(peak, n)
+		}
+	}
+
+	var b strings.Builder
+	for _, r := range rows {
+		fmt.Fprintf(&b, "%s%s%s  %d commits, per %s\n", colorBoldCyan, r.author, colorReset, r.total, period)
+		for i, n := range r.counts {
+			bar := strings.Repeat("█", (n*barWidth+peak-1)/peak)
+			fmt.Fprintf(&b, "  %-10s %s%s%s %d\n", labels[i], colorGreen, bar, colorReset, n)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBucketMatrix(t *testing.T) {
+	commits := []historyCommit{
+		{name: "John", email: "<john@example.com>", when: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Ann", email: "<ann@example.com>", when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	labels, rows := bucketMatrix(commits, "quarter")
+	if got := strings.Join(labels, ","); got != "2024-Q1,2024-Q2,2024-Q3,2024-Q4" {
+		t.Errorf("labels = %s", got)
+	}
+	var authors []string
+	for _, r := range rows {
+		authors = append(authors, r.author)
+	}
+	// Ranked by total, then by name
+	if got := strings.Join(authors, ","); got != "Jane <jane@example.com>,Ann <ann@example.com>,John <john@example.com>" {
+		t.Errorf("authors = %s", got)
+	}
+	if rows[0].total != 2 || rows[0].counts[0] != 1 || rows[0].counts[1] != 1 {
+		t.Errorf("unexpected row for Jane: %+v", rows[0])
+	}
+}
+
+func TestWriteBucketTable(t *testing.T) {
+	rows := []bucketRow{
+		{"Jane <jane@example.com>", []int{3, 1}, 4},
+		{"John <john@example.com>", []int{0, 2}, 2},
+	}
+	var b strings.Builder
+	writeBucketTable(&b, []string{"2023", "2024"}, rows)
+
+	want := "Author                    2023   2024  Total\n" +
+		"Jane <jane@example.com>      3      1      4\n" +
+		"John <john@example.com>      0      2      2\n" +
+		"Total                        3      3      6\n"
+	if b.String() != want {
+		t.Errorf("table =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestFormatBucketSeries(t *testing.T) {
+	rows := []bucketRow{{"Jane <jane@example.com>", []int{4, 0, 2}, 6}}
+	out := stripANSI(formatBucketSeries([]string{"2024-01", "2024-02", "2024-03"}, rows, "month"))
+
+	lines := strings.Split(out, "\n")
+	if !strings.Contains(lines[0], "Jane <jane@example.com>  6 commits, per month") {
+		t.Errorf("unexpected title line: %q", lines[0])
+	}
+	if strings.Count(lines[1], "█") != 40 || strings.Count(lines[2], "█") != 0 || strings.Count(lines[3], "█") != 20 {
+		t.Errorf("unexpected bars:\n%s", out)
+	}
+	if formatBucketSeries(nil, nil, "month") != "" {
+		t.Error("expected no series without rows")
+	}
+}
+
+func TestBucketSeries(t *testing.T) {
+	dir, git := testRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	oldWorkDir, oldGitArgs := workDir, gitArgs
+	defer func() { workDir, gitArgs = oldWorkDir, oldGitArgs }()
+	workDir = dir
+
+	// Shortlog-only options aren't passed on to git log
+	gitArgs = []string{"--group=author", "-s"}
+	if got := bucketSeries(nil, "", "month"); !strings.Contains(got, "1 commits, per month") {
+		t.Errorf("bucketSeries with shortlog-only options = %q", got)
+	}
+
+	// A failing git log shows its error instead of an empty section
+	gitArgs = []string{"no-such-revision"}
+	if got := bucketSeries(nil, "", "month"); !strings.Contains(got, "git log:") {
+		t.Errorf("bucketSeries for a bad revision = %q, want the git error", got)
+	}
+}
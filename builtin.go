@@ -0,0 +1,525 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// builtinFrontend shows the screens in the terminal itself: a list with a
+// fuzzy filter, multi-select and a preview pane, with the same keys as the
+// fzf frontend. Previews are generated in-process, in the background.
+type builtinFrontend struct{}
+
+// Previews for choice lists, by subcommand name (see choice.preview)
+var choicePreviews = map[string]func(w io.Writer, key string){
+	"_release": writeRelease,
+}
+
+// listScreen is a list as shown by the builtin frontend
+type listScreen struct {
+	prompt   string
+	header   string
+	info     string   // Shown after the counts
+	lines    []string // Items (may be colorized)
+	ids      []string // What identifies each item across updates
+	multi    bool
+	bound    map[string]bool // Printable keys that aren't typed into the query
+	query    string
+	matches  []int // Indexes of the items that match query
+	cursor   int   // Index into matches
+	top      int   // First match shown
+	selected map[string]bool
+
+	preview    bool // Whether there's a preview pane
+	previewKey string
+	previewTop int
+	previewed  []string // Lines of the preview
+}
+
+func newListScreen(prompt, header string) *listScreen {
+	return &listScreen{prompt: prompt, header: header, selected: make(map[string]bool)}
+}
+
+// setLines replaces the items, keeping the cursor on the same item if it's
+// still there
+func (s *listScreen) setLines(lines, ids []string) {
+	current := s.currentID()
+	s.lines, s.ids = lines, ids
+	s.filter()
+	for n, i := range s.matches {
+		if s.ids[i] == current {
+			s.cursor = n
+			break
+		}
+	}
+}
+
+// filter updates the matches for the query
+func (s *listScreen) filter() {
+	s.matches = s.matches[:0]
+	for i, line := range s.lines {
+		if fuzzyMatch(s.query, stripANSI(line)) {
+			s.matches = append(s.matches, i)
+		}
+	}
+	s.cursor = max(0, min(s.cursor, len(s.matches)-1))
+}
+
+// current returns the index of the item at the cursor, or -1 if none
+func (s *listScreen) current() int {
+	if len(s.matches) == 0 {
+		return -1
+	}
+	return s.matches[s.cursor]
+}
+
+func (s *listScreen) currentID() string {
+	if i := s.current(); i >= 0 {
+		return s.ids[i]
+	}
+	return ""
+}
+
+// chosen returns the indexes of the selected items, in list order, or else
+// the one at the cursor (like fzf's {+})
+func (s *listScreen) chosen() []int {
+	var result []int
+	for i, id := range s.ids {
+		if s.selected[id] {
+			result = append(result, i)
+		}
+	}
+	if len(result) == 0 && s.current() >= 0 {
+		result = append(result, s.current())
+	}
+	return result
+}
+
+// edit handles the keys that all lists share: typing into the query,
+// moving the cursor, selecting and scrolling the preview. It reports
+// whether key was one of them.
+func (s *listScreen) edit(key string, pageSize int) bool {
+	switch key {
+	case "up", "ctrl-k", "ctrl-p":
+		s.cursor = max(0, s.cursor-1)
+	case "down", "ctrl-j", "ctrl-n":
+		s.cursor = max(0, min(s.cursor+1, len(s.matches)-1))
+	case "page-up":
+		s.cursor = max(0, s.cursor-pageSize)
+	case "page-down":
+		s.cursor = max(0, min(s.cursor+pageSize, len(s.matches)-1))
+	case "home":
+		s.cursor = 0
+	case "end":
+		s.cursor = max(0, len(s.matches)-1)
+	case "ctrl-f":
+		s.previewTop = max(0, min(s.previewTop+pageSize, len(s.previewed)-1))
+	case "ctrl-b":
+		s.previewTop = max(0, s.previewTop-pageSize)
+	case "ctrl-t":
+		if !s.multi || s.current() < 0 {
+			return true
+		}
+		id := s.currentID()
+		if s.selected[id] {
+			delete(s.selected, id)
+		} else {
+			s.selected[id] = true
+		}
+	case "backspace":
+		if s.query != "" {
+			r := []rune(s.query)
+			s.query = string(r[:len(r)-1])
+			s.filter()
+		}
+	case "ctrl-u":
+		s.query = ""
+		s.filter()
+	default:
+		if len([]rune(key)) != 1 || !unicode.IsPrint([]rune(key)[0]) {
+			return false
+		}
+		s.query += key
+		s.filter()
+	}
+	return true
+}
+
+// setPreview shows text in the preview pane, scrolled to the top if it's
+// for a different key than before
+func (s *listScreen) setPreview(key, text string) {
+	if key != s.previewKey {
+		s.previewTop = 0
+	}
+	s.previewKey = key
+	s.previewed = strings.Split(strings.TrimRight(text, "\n"), "\n")
+	s.previewTop = min(s.previewTop, max(0, len(s.previewed)-1))
+}
+
+// frame renders the screen for a terminal of the given size
+func (s *listScreen) frame(width, height int) string {
+	var rows []string
+
+	info := fmt.Sprintf("%d/%d", len(s.matches), len(s.lines))
+	if len(s.selected) > 0 {
+		info += fmt.Sprintf(" (%d)", len(s.selected))
+	}
+	rows = append(rows, fitLine(colorCyan+s.prompt+colorReset+s.query+"\033[7m \033[0m  "+
+		colorGreen+info+colorReset+s.info, width))
+	for _, line := range strings.Split(s.header, "\n") {
+		rows = append(rows, fitLine(line, width))
+	}
+	rows = append(rows, fitLine("\033[2m"+strings.Repeat("─", width), width))
+
+	// The list on the left, and the preview (if any) on the right
+	listHeight := max(1, height-len(rows))
+	listWidth := width
+	if s.preview && width >= 40 {
+		listWidth = width - width/2 - 1
+	}
+	if s.cursor < s.top {
+		s.top = s.cursor
+	}
+	if s.cursor >= s.top+listHeight {
+		s.top = s.cursor - listHeight + 1
+	}
+	for row := 0; row < listHeight; row++ {
+		left := ""
+		if n := s.top + row; n < len(s.matches) {
+			i := s.matches[n]
+			pointer, marker := "  ", "  "
+			if n == s.cursor {
+				pointer = colorWhite + "▶ " + colorReset
+			}
+			if s.selected[s.ids[i]] {
+				marker = colorGreen + "✓ " + colorReset
+			}
+			left = pointer + marker + s.lines[i]
+		}
+		line := fitLine(left, listWidth)
+		if listWidth < width {
+			right := ""
+			if p := s.previewTop + row; p < len(s.previewed) {
+				right = s.previewed[p]
+			}
+			line += "\033[2m│\033[0m" + fitLine(right, width-listWidth-1)
+		}
+		rows = append(rows, line)
+	}
+	if len(rows) > height {
+		rows = rows[:height]
+	}
+	return "\033[H" + strings.Join(rows, "\r\n")
+}
+
+// fuzzyMatch reports whether each space-separated term of query matches
+// text, as fzf's default (fuzzy) matching does: the term's characters
+// appear in text, in order. Matching ignores case, unless the term has
+// upper case letters.
+func fuzzyMatch(query, text string) bool {
+	lower := strings.ToLower(text)
+	for _, term := range strings.Fields(query) {
+		haystack := text
+		if strings.ToLower(term) == term {
+			haystack = lower
+		}
+		for _, r := range term {
+			i := strings.IndexRune(haystack, r)
+			if i < 0 {
+				return false
+			}
+			haystack = haystack[i+len(string(r)):]
+		}
+	}
+	return true
+}
+
+// previewer generates previews in the background, and keeps them
+type previewer struct {
+	mu      sync.Mutex
+	cache   map[string]string
+	pending map[string]bool
+}
+
+func newPreviewer() *previewer {
+	return &previewer{cache: make(map[string]string), pending: make(map[string]bool)}
+}
+
+// get returns the preview for key if it's ready; if it isn't, it starts
+// generating it with generate
+func (p *previewer) get(key string, generate func(w io.Writer)) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if text, ok := p.cache[key]; ok {
+		return text, true
+	}
+	if !p.pending[key] {
+		p.pending[key] = true
+		go func() {
+			var b strings.Builder
+			generate(&b)
+			p.mu.Lock()
+			p.cache[key] = b.String()
+			delete(p.pending, key)
+			p.mu.Unlock()
+		}()
+	}
+	return "", false
+}
+
+// show runs the screen until handle reports that a key ended it. Before
+// every redraw, update is called, to refresh the list and the preview.
+func (t *terminal) show(s *listScreen, update func(), handle func(key string) bool) {
+	shown := ""
+	for {
+		update()
+		if t.resized() {
+			shown = ""
+		}
+		if f := s.frame(t.width, t.height); f != shown {
+			fmt.Fprint(t.tty, f)
+			shown = f
+		}
+
+		keys, err := t.readKeys()
+		if err != nil {
+			handle("esc")
+			return
+		}
+		for _, key := range keys {
+			if !s.bound[key] && s.edit(key, max(1, t.height-4)) {
+				continue
+			}
+			if handle(key) {
+				return
+			}
+			shown = "" // The key may have run something over the screen
+		}
+	}
+}
+
+// suspended runs f with the terminal back in its normal mode
+func (t *terminal) suspended(f func()) {
+	t.suspend()
+	fmt.Fprint(t.tty, "\033[H\033[2J")
+	f()
+	t.resume()
+}
+
+func (builtinFrontend) authors(list *authorList) (string, string, []string) {
+	t, err := openTerminal()
+	if err != nil {
+		return newPromptFrontend().authors(list)
+	}
+	defer t.close()
+
+	s := newListScreen("Filter by name/email or date > ", listHeader(list.date))
+	s.info = "  │ ? for help │"
+	s.multi = true
+	s.bound = map[string]bool{"?": true}
+	s.preview = true
+	setEntries := func(list string) {
+		var lines, ids []string
+		for _, line := range strings.Split(strings.TrimRight(list, "\n"), "\n") {
+			if line != "" {
+				lines = append(lines, line)
+				ids = append(ids, lineAuthor(line))
+			}
+		}
+		s.setLines(lines, ids)
+	}
+	emails := func() []string {
+		var result []string
+		for _, i := range s.chosen() {
+			result = append(result, lineEmail(s.lines[i]))
+		}
+		return result
+	}
+	// Preview modes, as with fzf: "" (commits), "help", "prs", "punchcard"
+	// or "churn"
+	mode := ""
+	var notice error // E.g. why the date typed wasn't applied; shown until the query changes
+	noticeQuery := ""
+	previews := newPreviewer()
+	shownVersion := -1
+	update := func() {
+		entries, _, version, _ := list.stream.snapshot()
+		if version != shownVersion {
+			setEntries(formatEntries(entries))
+			shownVersion = version
+		}
+		if notice != nil && s.query == noticeQuery {
+			s.header = errorHeader(listHeader(list.date), notice)
+		} else {
+			s.header = statusHeader(listHeader(list.date), list.stream)
+		}
+
+		emails := emails()
+		if len(emails) == 0 && mode != "help" && mode != "churn" {
+			s.setPreview("", "")
+			return
+		}
+		key := mode + "\x00" + list.date + "\x00" + strings.Join(emails, "\x00")
+		date := list.date
+		text, ok := previews.get(key, func(w io.Writer) {
+			writePreviewMode(w, mode, emails, date)
+		})
+		if !ok {
+			text = colorCyan + "Loading…" + colorReset
+		}
+		s.setPreview(key, text)
+	}
+
+	// Date filters are applied in place, keeping the cursor and the
+	// selection on the same authors; the query was the date, so it's cleared
+	refilter := func(ok bool) bool {
+		if ok {
+			s.query = ""
+			shownVersion = -1
+			notice = nil
+		}
+		return ok
+	}
+
+	action := ""
+	t.show(s, update, func(key string) bool {
+		switch key {
+		case "?", "alt-p", "alt-h", "alt-c":
+			newMode := map[string]string{"?": "help", "alt-p": "prs", "alt-h": "punchcard", "alt-c": "churn"}[key]
+			if mode == newMode {
+				newMode = ""
+			}
+			mode = newMode
+		case "tab":
+			if emails := emails(); len(emails) > 0 {
+				t.suspended(func() {
+					if !runPaged(diffsCommand(emails, list.date), t.tty) {
+						fmt.Fprint(t.tty, "\nPress Enter to go back...")
+						fmt.Fscanln(t.tty)
+					}
+				})
+			}
+		case "ctrl-w":
+			if i := s.current(); i >= 0 {
+				if err := openAuthorPage(lineEmail(s.lines[i]), list.date); err != nil {
+					notice, noticeQuery = err, s.query
+				}
+			}
+		case "enter", "ctrl-o":
+			if strings.TrimSpace(s.query) != "" {
+				if err := checkDate(s.query); err != nil {
+					notice, noticeQuery = err, s.query
+				} else {
+					list.filter(s.query)
+					refilter(true)
+				}
+			}
+		case "alt-d":
+			action = "dates"
+		case "esc", "ctrl-c":
+			if !refilter(list.back()) {
+				action = "back"
+			}
+		case "ctrl-q":
+			if !refilter(list.back()) {
+				action = "quit"
+			}
+		case "ctrl-r":
+			if hasRemoteChoice(remotes) {
+				action = "remote"
+			}
+		}
+		return action != ""
+	})
+
+	var selections []string
+	for _, i := range s.chosen() {
+		selections = append(selections, stripANSI(s.lines[i]))
+	}
+	return action, s.query, selections
+}
+
+func (builtinFrontend) choose(c choice) (int, bool) {
+	t, err := openTerminal()
+	if err != nil {
+		return newPromptFrontend().choose(c)
+	}
+	defer t.close()
+
+	s := newListScreen(c.prompt+" > ", colorYellow+c.header+colorReset)
+	var ids []string
+	for i := range c.items {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	s.setLines(c.items, ids)
+
+	generate := choicePreviews[c.preview]
+	s.preview = generate != nil
+	previews := newPreviewer()
+	update := func() {
+		i := s.current()
+		if !s.preview || i < 0 || i >= len(c.keys) {
+			s.setPreview("", "")
+			return
+		}
+		text, ok := previews.get(c.keys[i], func(w io.Writer) { generate(w, c.keys[i]) })
+		if !ok {
+			text = colorCyan + "Loading…" + colorReset
+		}
+		s.setPreview(c.keys[i], text)
+	}
+
+	chosen := false
+	t.show(s, update, func(key string) bool {
+		switch key {
+		case "enter":
+			chosen = s.current() >= 0
+			return chosen
+		case "esc", "ctrl-c", "ctrl-q":
+			return true
+		}
+		return false
+	})
+	return s.current(), chosen
+}
+
+func (builtinFrontend) compare(c comparison) (string, []string) {
+	t, err := openTerminal()
+	if err != nil {
+		return newPromptFrontend().compare(c)
+	}
+	defer t.close()
+
+	s := newListScreen("Author > ", c.header())
+	s.multi = true
+	var ids []string
+	for i := range c.lines {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	s.setLines(c.lines, ids)
+
+	action := ""
+	t.show(s, func() {}, func(key string) bool {
+		switch key {
+		case "ctrl-s":
+			action = "sort"
+		case "ctrl-q":
+			action = "quit"
+		case "enter", "esc", "ctrl-c":
+		default:
+			return false
+		}
+		return true
+	})
+
+	var selections []string
+	if action == "quit" {
+		for _, i := range s.chosen() {
+			selections = append(selections, stripANSI(s.lines[i]))
+		}
+	}
+	return action, selections
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        bool
+	}{
+		{"", "Jane Smith", true},
+		{"jsm", "Jane Smith <jane@example.com>", true},
+		{"mxj", "Jane Smith <jane@example.com>", false},
+		{"jane example", "Jane Smith <jane@example.com>", true},
+		{"jane gmail", "Jane Smith <jane@example.com>", false},
+		{"Jane", "jane smith", false}, // Upper case in the query: exact case
+	}
+	for _, tt := range tests {
+		if got := fuzzyMatch(tt.query, tt.text); got != tt.want {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, want %v", tt.query, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestListScreen(t *testing.T) {
+	s := newListScreen("> ", "Header")
+	s.multi = true
+	s.setLines([]string{"Jane", "John", "Mary"}, []string{"j1", "j2", "m"})
+
+	// Typing filters; the cursor stays within the matches
+	s.edit("down", 10)
+	s.edit("down", 10)
+	s.edit("j", 10)
+	if !reflect.DeepEqual(s.matches, []int{0, 1}) || s.current() != 1 {
+		t.Errorf("after typing j: matches %v, current %d", s.matches, s.current())
+	}
+
+	// Without a selection, the current item is chosen; with one, the selection
+	s.edit("ctrl-t", 10)
+	s.edit("backspace", 10)
+	s.edit("end", 10)
+	s.edit("ctrl-t", 10)
+	if got := s.chosen(); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("chosen = %v, want [1 2]", got)
+	}
+
+	// Updates keep the cursor and the selection on the same items
+	s.setLines([]string{"Mary", "Jane", "John"}, []string{"m", "j1", "j2"})
+	if s.currentID() != "m" || !reflect.DeepEqual(s.chosen(), []int{0, 2}) {
+		t.Errorf("after update: current %q, chosen %v", s.currentID(), s.chosen())
+	}
+
+	if s.edit("alt-p", 10) {
+		t.Error("alt-p should be left to the screen")
+	}
+}
+
+func TestListScreenFrame(t *testing.T) {
+	s := newListScreen("> ", "Header")
+	s.preview = true
+	s.setLines([]string{"one", "two", "three"}, []string{"1", "2", "3"})
+	s.setPreview("1", "first\nsecond")
+	s.edit("down", 10)
+
+	rows := strings.Split(stripANSI(strings.TrimPrefix(s.frame(40, 6), "\033[H")), "\r\n")
+	if len(rows) != 6 {
+		t.Fatalf("got %d rows, want 6", len(rows))
+	}
+	for i, want := range []string{"> ", "Header", "──", "    one", "▶   two", "    three"} {
+		if !strings.HasPrefix(rows[i], want) {
+			t.Errorf("row %d = %q, want it to start with %q", i, rows[i], want)
+		}
+	}
+	if !strings.Contains(rows[3], "│first") || !strings.Contains(rows[4], "│second") {
+		t.Errorf("preview not shown on the right: %q", rows[3:5])
+	}
+}
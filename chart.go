@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth  = 900
+	chartHeight = 420
+	// Plot area margins; the right one leaves room for the legend
+	chartMarginLeft   = 50
+	chartMarginRight  = 220
+	chartMarginTop    = 40
+	chartMarginBottom = 50
+)
+
+// Colors for the authors' series; the "others" series is always gray
+var chartPalette = []string{
+	"#4e79a7", "#f28e2b", "#e15759", "#76b7b2", "#59a14f",
+	"#edc948", "#b07aa1", "#ff9da7", "#9c755f", "#86bcb6",
+	"#d37295", "#a0cbe8", "#ffbe7d", "#8cd17d", "#b6992d",
+}
+
+const chartOthersColor = "#bab0ac"
+
+// Chart options, set with --chart, --chart-style, --chart-top, --chart-period and --chart-output
+var (
+	chartFormat = "" // Only "svg" so far; empty for interactive mode
+	chartStyle  = "bar"
+	chartTop    = 10
+	chartPeriod = "month"
+	chartOutput = "" // Empty or "-" for stdout
+)
+
+// chartSeries is one stacked layer of the chart
+type chartSeries struct {
+	label  string
+	color  string
+	counts []int // Commits per period
+}
+
+// runChart writes the chart for the current args, instead of starting the UI
+func runChart() {
+	if chartFormat != "svg" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported chart format %q (supported: svg)\n", chartFormat)
+		exit(2)
+	}
+	if chartStyle != "bar" && chartStyle != "area" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported chart style %q (supported: bar, area)\n", chartStyle)
+		exit(2)
+	}
+	if !validPeriod(chartPeriod) {
+		fmt.Fprintf(os.Stderr, "Error: unsupported period %q (supported: %s)\n", chartPeriod, strings.Join(periodNames, ", "))
+		exit(2)
+	}
+
+	commits, err := readHistory("", false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		exit(1)
+	}
+	requireCommits(len(commits))
+
+	// Rank authors by the same commits that the chart counts
+	starts, series := chartData(historyEntries(commits), commits, chartTop, chartPeriod)
+	title := fmt.Sprintf("Commits per %s: %s", chartPeriod, reportTitle())
+
+	out := os.Stdout
+	if chartOutput != "" && chartOutput != "-" {
+		f, err := os.Create(chartOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating chart: %v\n", err)
+			exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeSVGChart(out, title, starts, series, chartPeriod, chartStyle); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing chart: %v\n", err)
+		exit(1)
+	}
+}
+
+// chartData splits commits per period into one series for each of the top
+// ranked authors, plus one for everyone else
+func chartData(ranked []shortlogEntry, commits []historyCommit, top int, period string) ([]time.Time, []chartSeries) {
+	starts, counts := periodCounts(commits, period)
+
+	var series []chartSeries
+	inTop := make(map[string]bool)
+	for i, e := range ranked {
+		if i == top {
+			break
+		}
+		key := e.name + " " + e.email
+		inTop[key] = true
+		c := counts[key]
+		if c == nil {
+			c = make([]int, len(starts))
+		}
+		series = append(series, chartSeries{e.name, chartPalette[i%len(chartPalette)], c})
+	}
+
+	others := make([]int, len(starts))
+	hasOthers := false
+	for key, c := range counts {
+		if inTop[key] {
+			continue
+		}
+		for i, n := range c {
+			others[i] += n
+			hasOthers = hasOthers || n > 0
+		}
+	}
+	if hasOthers {
+		series = append(series, chartSeries{"Others", chartOthersColor, others})
+	}
+	return starts, series
+}
+
+// writeSVGChart renders series stacked per period, as bars or areas
+func writeSVGChart(w io.Writer, title string, starts []time.Time, series []chartSeries, period, style string) error {
+	plotW := float64(chartWidth - chartMarginLeft - chartMarginRight)
+	plotH := float64(chartHeight - chartMarginTop - chartMarginBottom)
+
+	// Stacked totals per period, to scale the y axis
+	maxTotal := 1
+	for i := range starts {
+		total := 0
+		for _, s := range series {
+			total += s.counts[i]
+		}
+		maxTotal = max(maxTotal, total)
+	}
+	yMax := niceCeiling(maxTotal)
+	y := func(n int) float64 {
+		return float64(chartMarginTop) + plotH - float64(n)/float64(yMax)*plotH
+	}
+
+	slot := plotW / float64(max(len(starts), 1))
+	x := func(i int) float64 { return float64(chartMarginLeft) + slot*float64(i) }
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">
+<rect width="100%%" height="100%%" fill="white"/>
+<text x="%d" y="24" font-size="16" font-weight="bold">%s</text>
+`, chartWidth, chartHeight, chartWidth, chartHeight, chartMarginLeft, html.EscapeString(title))
+
+	// Y axis grid lines and labels
+	step := niceCeiling(yMax / 5)
+	for n := 0; n <= yMax; n += max(step, 1) {
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e0e0e0"/>
+<text x="%d" y="%.1f" text-anchor="end" fill="#555">%d</text>
+`, chartMarginLeft, y(n), float64(chartMarginLeft)+plotW, y(n), chartMarginLeft-6, y(n)+4, n)
+	}
+
+	// X axis labels, thinned out so they don't overlap
+	labelEvery := max(1, int(60/slot)+1)
+	for i, start := range starts {
+		if i%labelEvery != 0 {
+			continue
+		}
+		fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle" fill="#555">%s</text>
+`, x(i)+slot/2, chartHeight-chartMarginBottom+18, periodLabel(start, period))
+	}
+
+	// The stacked series
+	base := make([]int, len(starts))
+	for _, s := range series {
+		if style == "area" {
+			var upper, lower []string
+			for i := range starts {
+				cx := x(i) + slot/2
+				upper = append(upper, fmt.Sprintf("%.1f,%.1f", cx, y(base[i]+s.counts[i])))
+				lower = append([]string{fmt.Sprintf("%.1f,%.1f", cx, y(base[i]))}, lower...)
+			}
+			fmt.Fprintf(&b, `<polygon points="%s %s" fill="%s" fill-opacity="0.85"><title>%s</title></polygon>
+`, strings.Join(upper, " "), strings.Join(lower, " "), s.color, html.EscapeString(s.label))
+		} else {
+			for i := range starts {
+				if s.counts[i] == 0 {
+					continue
+				}
+				top := y(base[i] + s.counts[i])
+				fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"><title>%s, %s: %d</title></rect>
+`, x(i)+slot*0.1, top, slot*0.8, y(base[i])-top, s.color,
+					html.EscapeString(s.label), periodLabel(starts[i], period), s.counts[i])
+			}
+		}
+		for i := range starts {
+			base[i] += s.counts[i]
+		}
+	}
+
+	// Axis line and legend (top of the stack first, like the chart)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#555"/>
+`, chartMarginLeft, y(0), float64(chartMarginLeft)+plotW, y(0))
+	legendX := chartWidth - chartMarginRight + 20
+	for i := len(series) - 1; i >= 0; i-- {
+		row := len(series) - 1 - i
+		ly := chartMarginTop + row*20
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="12" height="12" fill="%s"/>
+<text x="%d" y="%d">%s</text>
+`, legendX, ly, series[i].color, legendX+18, ly+10, html.EscapeString(series[i].label))
+	}
+
+	b.WriteString("</svg>\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// niceCeiling rounds n up to 1, 2 or 5 times a power of ten, for axis scales
+func niceCeiling(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	for magnitude := 1; ; magnitude *= 10 {
+		for _, f := range []int{1, 2, 5} {
+			if f*magnitude >= n {
+				return f * magnitude
+			}
+		}
+	}
+}
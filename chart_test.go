@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChartData(t *testing.T) {
+	ranked := []shortlogEntry{
+		{3, "Jane", "<jane@example.com>"},
+		{2, "John", "<john@example.com>"},
+		{1, "Ann", "<ann@example.com>"},
+	}
+	commits := []historyCommit{
+		{name: "Ann", email: "<ann@example.com>", when: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "John", email: "<john@example.com>", when: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "John", email: "<john@example.com>", when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	starts, series := chartData(ranked, commits, 2, "month")
+	if len(starts) != 3 {
+		t.Fatalf("expected 3 months, got %v", starts)
+	}
+	var labels []string
+	for _, s := range series {
+		labels = append(labels, s.label)
+	}
+	if want := []string{"Jane", "John", "Others"}; !reflect.DeepEqual(labels, want) {
+		t.Fatalf("series = %v, want %v", labels, want)
+	}
+	if want := []int{2, 1, 0}; !reflect.DeepEqual(series[0].counts, want) {
+		t.Errorf("Jane's counts = %v, want %v", series[0].counts, want)
+	}
+	if want := []int{0, 0, 1}; !reflect.DeepEqual(series[2].counts, want) {
+		t.Errorf("others' counts = %v, want %v", series[2].counts, want)
+	}
+	if series[2].color != chartOthersColor {
+		t.Errorf("others' color = %q, want %q", series[2].color, chartOthersColor)
+	}
+
+	// No "Others" series when every author is shown
+	if _, series := chartData(ranked, commits, 10, "month"); len(series) != 3 {
+		t.Errorf("expected 3 series with top 10, got %d", len(series))
+	}
+}
+
+func TestWriteSVGChart(t *testing.T) {
+	starts := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+	series := []chartSeries{
+		{"Jane <script>", "#4e79a7", []int{3, 0}},
+		{"Others", chartOthersColor, []int{1, 2}},
+	}
+
+	for _, style := range []string{"bar", "area"} {
+		var b strings.Builder
+		if err := writeSVGChart(&b, "Commits per month: org/repo", starts, series, "month", style); err != nil {
+			t.Fatalf("writeSVGChart(%s): %v", style, err)
+		}
+		svg := b.String()
+		if !strings.HasPrefix(svg, "<?xml") || !strings.HasSuffix(svg, "</svg>\n") {
+			t.Errorf("%s: not a standalone SVG document", style)
+		}
+		for _, want := range []string{"org/repo", "2024-01", "2024-02", "Jane &lt;script&gt;", "Others"} {
+			if !strings.Contains(svg, want) {
+				t.Errorf("%s: expected %q in chart", style, want)
+			}
+		}
+		if strings.Contains(svg, "<script>") {
+			t.Errorf("%s: author name not escaped", style)
+		}
+	}
+}
+
+func TestNiceCeiling(t *testing.T) {
+	tests := map[int]int{0: 1, 1: 1, 3: 5, 7: 10, 12: 20, 45: 50, 101: 200}
+	for n, want := range tests {
+		if got := niceCeiling(n); got != want {
+			t.Errorf("niceCeiling(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
+
+func TestParseArgsChart(t *testing.T) {
+	oldGitArgs, oldWorkDir := gitArgs, workDir
+	oldFormat, oldOutput, oldTop, oldPeriod := chartFormat, chartOutput, chartTop, chartPeriod
+	defer func() {
+		gitArgs, workDir = oldGitArgs, oldWorkDir
+		chartFormat, chartOutput, chartTop, chartPeriod = oldFormat, oldOutput, oldTop, oldPeriod
+	}()
+	gitArgs, workDir = nil, ""
+
+	parseArgs([]string{"--chart=svg", "--chart-output=a.svg", "--chart-top=5", "--chart-period=quarter", "--since=2024-01-01"})
+	if chartFormat != "svg" || chartOutput != "a.svg" || chartTop != 5 || chartPeriod != "quarter" {
+		t.Errorf("chart options: %q %q %d %q", chartFormat, chartOutput, chartTop, chartPeriod)
+	}
+	if !reflect.DeepEqual(gitArgs, []string{"--since=2024-01-01"}) {
+		t.Errorf("gitArgs = %q", gitArgs)
+	}
+}
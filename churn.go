@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// churnAuthor is an author in one of the churn comparison's groups
+type churnAuthor struct {
+	author  string // "Name <email>", as in git shortlog -e
+	commits int    // In the period (in the previous one, for lapsed authors)
+}
+
+// churn classifies the authors of a period by comparing it with the
+// previous period of the same length
+type churn struct {
+	start, prevStart time.Time
+	firstTime        []churnAuthor // No commits before the period
+	returning        []churnAuthor // Commits before the previous period, but not in it
+	continuing       []churnAuthor // Commits in the previous period too
+	lapsed           []churnAuthor // Commits in the previous period, but not in this one
+}
+
+// Comparisons already made, by range and date filter, so that refreshing
+// the preview doesn't walk the whole history again
+var (
+	churnMu    sync.Mutex
+	churnCache = make(map[string]churn)
+)
+
+// git log options that limit commits by date
+var dateLimitFlags = []string{"--since", "--after", "--until", "--before", "--max-age", "--min-age", "--since-as-filter"}
+
+// Subcommand: _churn
+func runChurnSubcommand() {
+	// The parent makes the comparison, so that it's made once per filter
+	if path := os.Getenv("GH_SHORTLOG_SOCKET"); path != "" {
+		resp, err := controlClient(path).Get("http://control/churn")
+		if err != nil {
+			fmt.Fprintf(os.Stdout, "\n%s%v%s\n", colorRed, err, colorReset)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(os.Stdout, resp.Body)
+		return
+	}
+	parseArgs(nil)
+	writeChurn(os.Stdout, dateFilter)
+}
+
+// writeChurn writes the churn comparison of the period since sinceDate
+// with the one before, as shown in the preview
+func writeChurn(w io.Writer, sinceDate string) {
+	if sinceDate == "" {
+		fmt.Fprintf(w, "\n%sFilter by a date first%s (type e.g. \"3 months ago\", then Enter)\n", colorYellow, colorReset)
+		fmt.Fprintln(w, "to compare the authors since then with those of the period before.")
+		return
+	}
+
+	start, ok := resolveDate(sinceDate)
+	if !ok {
+		fmt.Fprintf(w, "\nCan't compare periods: %q isn't a date git understands\n", sinceDate)
+		return
+	}
+	ch, err := cachedChurn(sinceDate, start)
+	if err != nil {
+		fmt.Fprintf(w, "\n%s%v%s\n", colorRed, err, colorReset)
+		return
+	}
+	fmt.Fprint(w, formatChurn(ch))
+}
+
+// cachedChurn returns the comparison of the period since sinceDate (which
+// starts at start) with the one before, making it only the first time for
+// the range in gitArgs
+func cachedChurn(sinceDate string, start time.Time) (churn, error) {
+	churnMu.Lock()
+	defer churnMu.Unlock()
+	key := strings.Join(gitArgs, "\x00") + "\x00\x00" + sinceDate
+	if ch, ok := churnCache[key]; ok {
+		return ch, nil
+	}
+
+	// Date limits given as git options would cut off the history that
+	// tells returning authors from first-time ones
+	commits, err := readHistoryOf(withoutDateLimits(gitArgs), "", false)
+	if err != nil {
+		return churn{}, err
+	}
+	ch := classifyChurn(commits, start, time.Now())
+	churnCache[key] = ch
+	return ch, nil
+}
+
+// withoutDateLimits returns args without the options that limit commits by
+// date (before any "--")
+func withoutDateLimits(args []string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		limit := false
+		for _, flag := range dateLimitFlags {
+			if arg == flag && i+1 < len(args) {
+				i++ // The date is the next argument
+				limit = true
+			} else if strings.HasPrefix(arg, flag+"=") {
+				limit = true
+			}
+		}
+		if !limit {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
+
+// resolveDate turns a date as given to --since (e.g. "3 months ago") into a
+// time, the way git itself parses it
+func resolveDate(date string) (time.Time, bool) {
+	out, err := gitCommand("rev-parse", "--since="+date).Output()
+	if err != nil {
+		return time.Time{}, false
+	}
+	secs, err := strconv.ParseInt(strings.TrimPrefix(strings.TrimSpace(string(out)), "--max-age="), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
+
+// classifyChurn compares the authors of the period from start to now with
+// those of the period of the same length before it
+func classifyChurn(commits []historyCommit, start, now time.Time) churn {
+	ch := churn{start: start, prevStart: start.Add(-now.Sub(start))}
+
+	current := make(map[string]int)
+	previous := make(map[string]int)
+	earlier := make(map[string]bool)
+	for _, c := range commits {
+		key := authorKey(c)
+		switch {
+		case !c.when.Before(start):
+			current[key]++
+		case !c.when.Before(ch.prevStart):
+			previous[key]++
+		default:
+			earlier[key] = true
+		}
+	}
+
+	for key, n := range current {
+		a := churnAuthor{key, n}
+		switch {
+		case previous[key] > 0:
+			ch.continuing = append(ch.continuing, a)
+		case earlier[key]:
+			ch.returning = append(ch.returning, a)
+		default:
+			ch.firstTime = append(ch.firstTime, a)
+		}
+	}
+	for key, n := range previous {
+		if current[key] == 0 {
+			ch.lapsed = append(ch.lapsed, churnAuthor{key, n})
+		}
+	}
+
+	for _, group := range [][]churnAuthor{ch.firstTime, ch.returning, ch.continuing, ch.lapsed} {
+		sort.Slice(group, func(i, j int) bool {
+			if group[i].commits != group[j].commits {
+				return group[i].commits > group[j].commits
+			}
+			return group[i].author < group[j].author
+		})
+	}
+	return ch
+}
+
+// formatChurn renders the counts, then each group's authors, for the preview
+func formatChurn(ch churn) string {
+	groups := []struct {
+		title   string
+		note    string
+		authors []churnAuthor
+	}{
+		{"First-time", "first commits in this period", ch.firstTime},
+		{"Returning", "back after a gap of at least one period", ch.returning},
+		{"Continuing", "also active in the previous period", ch.continuing},
+		{"Lapsed", "active in the previous period, but not this one", ch.lapsed},
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%sAuthors since %s%s, compared with %s to %s\n\n", colorBoldCyan,
+		ch.start.Format("2006-01-02"), colorReset, ch.prevStart.Format("2006-01-02"), ch.start.Format("2006-01-02"))
+	for _, g := range groups {
+		fmt.Fprintf(&b, "  %-11s %s%4d%s\n", g.title, colorWhite, len(g.authors), colorReset)
+	}
+	for _, g := range groups {
+		if len(g.authors) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s%s%s (%s)\n", colorYellow, g.title, colorReset, g.note)
+		for _, a := range g.authors {
+			fmt.Fprintf(&b, "  %s%4d%s  %s\n", colorGreen, a.commits, colorReset, a.author)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifyChurn(t *testing.T) {
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	start := now.AddDate(0, -3, 0) // Previous period starts 91 days before that
+	at := func(t time.Time, name string) historyCommit {
+		return historyCommit{name: name, email: "<" + strings.ToLower(name) + "@example.com>", when: t}
+	}
+	commits := []historyCommit{
+		at(now.AddDate(0, 0, -1), "New"),
+		at(now.AddDate(0, 0, -2), "New"),
+		at(now.AddDate(0, -1, 0), "Steady"),
+		at(now.AddDate(0, -2, 0), "Back"),
+		at(start, "Steady"), // The period includes its start
+		at(start.AddDate(0, 0, -10), "Steady"),
+		at(start.AddDate(0, 0, -20), "Gone"),
+		at(start.AddDate(-1, 0, 0), "Back"),
+		at(start.AddDate(-1, 0, 0), "Gone"),
+	}
+
+	ch := classifyChurn(commits, start, now)
+	if want := start.AddDate(0, 0, -91); !ch.prevStart.Equal(want) {
+		t.Errorf("prevStart = %v, want %v", ch.prevStart, want)
+	}
+	check := func(group string, got []churnAuthor, want ...churnAuthor) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Errorf("%s = %v, want %v", group, got, want)
+			return
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s = %v, want %v", group, got, want)
+				return
+			}
+		}
+	}
+	check("firstTime", ch.firstTime, churnAuthor{"New <new@example.com>", 2})
+	check("returning", ch.returning, churnAuthor{"Back <back@example.com>", 1})
+	check("continuing", ch.continuing, churnAuthor{"Steady <steady@example.com>", 2})
+	check("lapsed", ch.lapsed, churnAuthor{"Gone <gone@example.com>", 1})
+
+	out := stripANSI(formatChurn(ch))
+	for _, want := range []string{"Authors since 2024-04-01", "First-time     1", "Lapsed         1", "   2  New <new@example.com>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestResolveDate(t *testing.T) {
+	dir, _ := testRepo(t)
+	oldWorkDir := workDir
+	defer func() { workDir = oldWorkDir }()
+	workDir = dir
+
+	got, ok := resolveDate("2024-03-05 12:00:00 +0000")
+	if want := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC); !ok || !got.Equal(want) {
+		t.Errorf("resolveDate = %v, %v; want %v", got, ok, want)
+	}
+	if got, ok := resolveDate("1 week ago"); !ok || time.Since(got) < 6*24*time.Hour {
+		t.Errorf("resolveDate(1 week ago) = %v, %v", got, ok)
+	}
+}
+
+func TestCachedChurn(t *testing.T) {
+	dir, git := testRepo(t)
+	savedArgs, savedDir := gitArgs, workDir
+	defer func() { gitArgs, workDir = savedArgs, savedDir }()
+	workDir = dir
+	gitArgs = nil
+
+	git("commit", "-q", "--allow-empty", "-m", "Old", "--date=2020-01-01T00:00:00Z")
+	git("commit", "-q", "--allow-empty", "-m", "New")
+	start := time.Now().AddDate(0, -1, 0)
+	ch, err := cachedChurn("1 month ago", start)
+	if err != nil || len(ch.returning) != 1 || len(ch.firstTime) != 0 {
+		t.Fatalf("cachedChurn = %+v, %v; want one returning author", ch, err)
+	}
+
+	// Refreshes reuse the comparison, while other ranges get their own
+	git("commit", "-q", "--allow-empty", "-m", "Another", "--author=John Doe <john@example.com>")
+	if ch, _ := cachedChurn("1 month ago", start); len(ch.firstTime) != 0 {
+		t.Errorf("cached comparison changed: %+v", ch)
+	}
+	gitArgs = []string{"--no-merges"}
+	if ch, _ := cachedChurn("1 month ago", start); len(ch.firstTime) != 1 {
+		t.Errorf("comparison for another range: %+v, want one first-time author", ch)
+	}
+}
+
+func TestWithoutDateLimits(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"--since=1 year ago", "--no-merges", "main"}, []string{"--no-merges", "main"}},
+		{[]string{"--after", "2024-01-01", "--until=2024-06-01", "--max-age=1700000000"}, nil},
+		{[]string{"--before", "yesterday", "--", "--since=x"}, []string{"--", "--since=x"}},
+	}
+	for _, tt := range tests {
+		if got := withoutDateLimits(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("withoutDateLimits(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Options for clones that leave out history or file contents
+var (
+	deepen bool      // Fetch what a shallow clone is missing, or let a partial clone fetch file contents for line counts
+	clone  cloneInfo // What the repository leaves out, as found by setupClone
+)
+
+// cloneInfo is what a shallow (git clone --depth) or partial (git clone
+// --filter) clone leaves out
+type cloneInfo struct {
+	shallow bool   // Commits before the cut-off aren't there
+	filter  string // Objects left out, e.g. "blob:none"; empty if not a partial clone
+}
+
+// detectClone finds out whether the repository is a shallow or partial clone
+func detectClone() cloneInfo {
+	var c cloneInfo
+	if out, err := gitCommand("rev-parse", "--is-shallow-repository").Output(); err == nil {
+		c.shallow = strings.TrimSpace(string(out)) == "true"
+	}
+	// A partial clone remembers its filter for the remote it came from
+	// ("remote.origin.partialclonefilter blob:none")
+	if out, err := gitCommand("config", "--get-regexp", `^remote\..*\.partialclonefilter$`).Output(); err == nil {
+		line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+		_, c.filter, _ = strings.Cut(line, " ")
+	}
+	return c
+}
+
+// missingContents reports whether a partial clone leaves out file contents,
+// which git would then fetch one at a time for --numstat. With blob filters
+// (blob:none, blob:limit), listing the files changed instead fetches
+// nothing; tree filters (tree:0) leave out the trees as well, which git
+// still fetches commit by commit to list them
+func (c cloneInfo) missingContents() bool {
+	return c.filter != ""
+}
+
+// headerNote returns the warning for the author list's header, if commits
+// are missing from the counts
+func (c cloneInfo) headerNote() string {
+	if c.shallow {
+		return "Shallow clone: older commits aren't counted (--deepen fetches them)"
+	}
+	return ""
+}
+
+// setupClone checks whether the repository is a shallow or partial clone,
+// and with --deepen, fetches the rest of a shallow clone's history
+func setupClone() {
+	clone = detectClone()
+	if !deepen || !clone.shallow {
+		return
+	}
+	fmt.Fprintln(os.Stderr, "Fetching the rest of the history…")
+	cmd := gitCommand("fetch", "--unshallow")
+	cmd.Stdout, cmd.Stderr = os.Stderr, os.Stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error fetching the history: %v\n", err)
+		exit(1)
+	}
+	clone.shallow = false
+}
+
+// warnClone warns, for the modes that print their result, that commits are
+// missing from it, or (if withFiles) that line counts are left out
+func warnClone(withFiles bool) {
+	if note := clone.headerNote(); note != "" {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", note)
+	}
+	if withFiles && clone.missingContents() && !deepen {
+		fmt.Fprintf(os.Stderr, "Warning: partial clone (%s): each file changed counts as one line, as git would fetch every file's contents for line counts (--deepen lets it)\n", clone.filter)
+	}
+}
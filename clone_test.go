@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectClone(t *testing.T) {
+	origin, git := testRepo(t)
+	oldWorkDir := workDir
+	defer func() { workDir = oldWorkDir }()
+
+	git("config", "uploadpack.allowFilter", "true")
+	git("commit", "-q", "--allow-empty", "-m", "one")
+	git("commit", "-q", "--allow-empty", "-m", "two")
+	base := t.TempDir()
+	git("clone", "-q", "--depth=1", "file://"+origin, filepath.Join(base, "shallow"))
+	git("clone", "-q", "--filter=blob:none", "file://"+origin, filepath.Join(base, "blobless"))
+
+	tests := []struct {
+		dir  string
+		want cloneInfo
+	}{
+		{origin, cloneInfo{}},
+		{filepath.Join(base, "shallow"), cloneInfo{shallow: true}},
+		{filepath.Join(base, "blobless"), cloneInfo{filter: "blob:none"}},
+	}
+	for _, tt := range tests {
+		workDir = tt.dir
+		if got := detectClone(); got != tt.want {
+			t.Errorf("%s: detectClone() = %+v, want %+v", tt.dir, got, tt.want)
+		}
+	}
+
+	if note := (cloneInfo{shallow: true}).headerNote(); !strings.Contains(note, "--deepen") {
+		t.Errorf("headerNote() = %q, want a hint about --deepen", note)
+	}
+	if !(cloneInfo{filter: "tree:0"}).missingContents() || (cloneInfo{shallow: true}).missingContents() {
+		t.Error("missingContents() should be true for partial clones only")
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Ranges given with --compare A B (empty when not comparing)
+var compareRanges []string
+
+// Orders that the comparison can be sorted in, cycled through with ^S
+var compareSorts = []string{"gain", "loss", "first", "second"}
+
+// How each of compareSorts is described in the header
+var compareSortNames = map[string]string{
+	"gain":   "biggest gain",
+	"loss":   "biggest drop",
+	"first":  "commits in the first range",
+	"second": "commits in the second range",
+}
+
+// compareRow is one author's commit counts in the two compared ranges
+type compareRow struct {
+	name   string
+	email  string
+	first  int
+	second int
+}
+
+func (r compareRow) delta() int { return r.second - r.first }
+
+// runCompare shows the authors of both ranges side by side, until exit
+func runCompare() {
+	// Each range is shortlogged like a revision given on the command line,
+	// with the rest of the args (options, paths) applied to both
+	baseArgs := gitArgs
+	var entries [2][]shortlogEntry
+	for i, rng := range compareRanges {
+		gitArgs = append([]string{rng}, baseArgs...)
+		var err error
+		if entries[i], err = generateShortlogEntries(""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
+	gitArgs = baseArgs
+	rows := compareEntries(entries[0], entries[1])
+	requireCommits(len(rows))
+
+	sortBy := compareSorts[0]
+	for {
+		sortCompare(rows, sortBy)
+		c := comparison{ranges: [2]string(compareRanges), sortBy: sortBy}
+		c.lines = strings.Split(strings.TrimRight(formatCompare(rows), "\n"), "\n")
+		action, selections := ui.compare(c)
+		switch action {
+		case "sort":
+			// Next sort order
+			for i, s := range compareSorts {
+				if s == sortBy {
+					sortBy = compareSorts[(i+1)%len(compareSorts)]
+					break
+				}
+			}
+		case "quit":
+			for _, sel := range selections {
+				fmt.Println(sel)
+			}
+			return
+		default:
+			return
+		}
+	}
+}
+
+// compareEntries pairs up the authors of two shortlogs
+func compareEntries(first, second []shortlogEntry) []compareRow {
+	index := make(map[string]int)
+	var rows []compareRow
+	row := func(e shortlogEntry) *compareRow {
+		key := e.name + " " + e.email
+		i, ok := index[key]
+		if !ok {
+			i = len(rows)
+			index[key] = i
+			rows = append(rows, compareRow{name: e.name, email: e.email})
+		}
+		return &rows[i]
+	}
+	for _, e := range first {
+		row(e).first += e.count
+	}
+	for _, e := range second {
+		row(e).second += e.count
+	}
+	return rows
+}
+
+// sortCompare orders rows by one of compareSorts, then by name
+func sortCompare(rows []compareRow, by string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		var ka, kb int
+		switch by {
+		case "loss":
+			ka, kb = -a.delta(), -b.delta()
+		case "first":
+			ka, kb = a.first, b.first
+		case "second":
+			ka, kb = a.second, b.second
+		default:
+			ka, kb = a.delta(), b.delta()
+		}
+		if ka != kb {
+			return ka > kb
+		}
+		return a.name < b.name
+	})
+}
+
+// formatCompare renders rows as aligned columns: both counts, the change,
+// then the author
+func formatCompare(rows []compareRow) string {
+	maxName := 0
+	for _, r := range rows {
+		maxName = max(maxName, len(r.name))
+	}
+
+	var b strings.Builder
+	for _, r := range rows {
+		deltaColor := colorWhite
+		switch {
+		case r.delta() > 0:
+			deltaColor = colorGreen
+		case r.delta() < 0:
+			deltaColor = colorYellow
+		}
+		fmt.Fprintf(&b, "%6d  %6d  %s%+6d%s  %s%-*s%s  %s%s%s\n",
+			r.first, r.second, deltaColor, r.delta(), colorReset,
+			colorWhite, maxName, r.name, colorReset, colorCyan, r.email, colorReset)
+	}
+	return b.String()
+}
+
+// launchCompareFzf shows the comparison and returns the key pressed and
+// the selected lines
+func launchCompareFzf(c comparison) (key string, selections []string) {
+	fzfArgs := []string{
+		"--ansi",
+		"--border=rounded",
+		"--layout=reverse",
+		"--pointer", "▶",
+		"--multi",
+		"--no-sort",
+		"--expect", "ctrl-s,ctrl-q,ctrl-c,esc,enter",
+		"--bind", "ctrl-t:toggle",
+		"--header", c.header(),
+		"--prompt", "Author > ",
+		"--color", "fg:15,bg:-1,hl:1",
+		"--color", "header:green:italic",
+		"--color", "prompt:80,info:40",
+		"--color", "border:dim",
+	}
+	if noMouse {
+		fzfArgs = append(fzfArgs, "--no-mouse")
+	}
+
+	cmd := exec.Command("fzf", fzfArgs...)
+	cmd.Stdin = strings.NewReader(strings.Join(c.lines, "\n") + "\n")
+	cmd.Stderr = os.Stderr
+	out, _ := cmd.Output() // Exits non-zero on esc/ctrl-c, but --expect still prints the key
+
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return lines[0], lines[1:]
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareEntries(t *testing.T) {
+	first := []shortlogEntry{
+		{5, "Jane", "<jane@example.com>"},
+		{3, "John", "<john@example.com>"},
+	}
+	second := []shortlogEntry{
+		{8, "Ann", "<ann@example.com>"},
+		{4, "Jane", "<jane@example.com>"},
+	}
+
+	rows := compareEntries(first, second)
+	want := []compareRow{
+		{"Jane", "<jane@example.com>", 5, 4},
+		{"John", "<john@example.com>", 3, 0},
+		{"Ann", "<ann@example.com>", 0, 8},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("compareEntries = %+v, want %+v", rows, want)
+	}
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{"gain", []string{"Ann", "Jane", "John"}},
+		{"loss", []string{"John", "Jane", "Ann"}},
+		{"first", []string{"Jane", "John", "Ann"}},
+		{"second", []string{"Ann", "Jane", "John"}},
+	}
+	for _, tt := range tests {
+		sortCompare(rows, tt.by)
+		var names []string
+		for _, r := range rows {
+			names = append(names, r.name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("sortCompare(%s) = %v, want %v", tt.by, names, tt.want)
+		}
+	}
+}
+
+func TestFormatCompare(t *testing.T) {
+	rows := []compareRow{
+		{"Ann", "<ann@example.com>", 0, 8},
+		{"Jane", "<jane@example.com>", 5, 4},
+	}
+	got := stripANSI(formatCompare(rows))
+	want := "     0       8      +8  Ann   <ann@example.com>\n" +
+		"     5       4      -1  Jane  <jane@example.com>\n"
+	if got != want {
+		t.Errorf("formatCompare =\n%s\nwant\n%s", got, want)
+	}
+	for _, s := range compareSorts {
+		if compareSortNames[s] == "" {
+			t.Errorf("no description for sort order %q", s)
+		}
+	}
+}
+
+func TestComparisonHeader(t *testing.T) {
+	c := comparison{ranges: [2]string{"v1.0..v2.0", "v2.0..v3.0"}, sortBy: "loss"}
+	header := stripANSI(c.header())
+	for _, want := range []string{"v1.0..v2.0  vs.  v2.0..v3.0", "Sorted by biggest drop", "\n" + compareColumns} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header %q doesn't contain %q", header, want)
+		}
+	}
+}
+
+func TestParseArgsCompare(t *testing.T) {
+	oldGitArgs := gitArgs
+	oldWorkDir := workDir
+	oldCompareRanges := compareRanges
+	defer func() {
+		gitArgs = oldGitArgs
+		workDir = oldWorkDir
+		compareRanges = oldCompareRanges
+	}()
+	gitArgs = nil
+	workDir = ""
+	compareRanges = nil
+
+	parseArgs([]string{"--compare", "v1.0..v2.0", "v2.0..v3.0", "--", "src/"})
+
+	if want := []string{"v1.0..v2.0", "v2.0..v3.0"}; !reflect.DeepEqual(compareRanges, want) {
+		t.Errorf("compareRanges = %v, want %v", compareRanges, want)
+	}
+	if got := strings.Join(gitArgs, " "); got != "-- src/" {
+		t.Errorf("gitArgs = %q, want %q", got, "-- src/")
+	}
+}
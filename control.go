@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// The control server is how subcommands run from fzf (or the prompt
+// frontend) get the parent's state: the parent listens on a Unix socket in
+// a private temp dir, which is removed on exit, and passes its path in
+// GH_SHORTLOG_SOCKET. Subcommands get the state from GET /state, and
+// change the preview mode with POST /preview-mode. GET /churn returns the
+// comparison with the previous period, which the parent keeps for later
+// refreshes of the preview. POST /filter applies a date filter to the list
+// that fzf is showing, and returns the fzf actions that show the result.
+
+// controlState is the parent's state that subcommands need
+type controlState struct {
+	Args        []string `json:"args"`
+	Dir         string   `json:"dir"`
+	Date        string   `json:"date"`
+	BaseURL     string   `json:"baseURL"`
+	OrgRepo     string   `json:"orgRepo"`
+	Forge       string   `json:"forge"`
+	Host        string   `json:"host"`
+	Remote      string   `json:"remote"`
+	Bucket      string   `json:"bucket"`
+	PunchcardTZ string   `json:"punchcardTZ"`
+	PreviewMode string   `json:"previewMode"`
+}
+
+// Preview modes: empty for the commit preview, or else the keybindings
+// help, the authors' pull requests, their commit times or the comparison
+// with the previous period
+var previewModes = []string{"help", "prs", "punchcard", "churn"}
+
+var (
+	controlMu   sync.Mutex // Guards dateFilter and previewMode while the server runs
+	previewMode string
+
+	controlPath string // Socket of the control server
+	controlDir  string // Temp dir the socket is in
+
+	// Applies a date filter to the list being shown (or goes back to the
+	// previous one), keeping the author at the cursor and the selected ones,
+	// and returns the fzf actions to show it
+	liveFilter func(req filterRequest) string
+)
+
+// filterRequest is what _filter sends to POST /filter
+type filterRequest struct {
+	Date     string   `json:"date"`
+	Back     bool     `json:"back"`     // Go back to the previous date filter instead
+	Current  string   `json:"current"`  // Email of the author at the cursor
+	Selected []string `json:"selected"` // Emails of the selected authors
+}
+
+// currentState returns the state that the control server serves
+func currentState() controlState {
+	controlMu.Lock()
+	defer controlMu.Unlock()
+	return controlState{
+		Args:        gitArgs,
+		Dir:         workDir,
+		Date:        dateFilter,
+		BaseURL:     baseURL,
+		OrgRepo:     orgAndRepo,
+		Forge:       forgeKind,
+		Host:        forgeHost,
+		Remote:      remoteName,
+		Bucket:      bucketPeriod,
+		PunchcardTZ: punchcardZone,
+		PreviewMode: previewMode,
+	}
+}
+
+// applyState sets the globals from a state fetched from the parent
+func applyState(s controlState) {
+	gitArgs = s.Args
+	workDir = s.Dir
+	dateFilter = s.Date
+	baseURL = s.BaseURL
+	orgAndRepo = s.OrgRepo
+	forgeKind = s.Forge
+	forgeHost = s.Host
+	remoteName = s.Remote
+	bucketPeriod = s.Bucket
+	punchcardZone = s.PunchcardTZ
+	previewMode = s.PreviewMode
+}
+
+// setDateFilter changes the date filter that subcommands see
+func setDateFilter(date string) {
+	controlMu.Lock()
+	dateFilter = date
+	controlMu.Unlock()
+}
+
+// resetPreviewMode switches the preview back to showing commits
+func resetPreviewMode() {
+	controlMu.Lock()
+	previewMode = ""
+	controlMu.Unlock()
+}
+
+// togglePreviewMode switches the preview to mode, or back to the commit
+// preview if it's already showing mode
+func togglePreviewMode(mode string) {
+	controlMu.Lock()
+	defer controlMu.Unlock()
+	if previewMode == mode {
+		previewMode = ""
+	} else {
+		previewMode = mode
+	}
+}
+
+// setLiveFilter sets (or with nil, clears) the function that POST /filter
+// runs
+func setLiveFilter(f func(req filterRequest) string) {
+	controlMu.Lock()
+	liveFilter = f
+	controlMu.Unlock()
+}
+
+// controlHandler serves the state, and preview mode changes
+func controlHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(currentState())
+	})
+	mux.HandleFunc("POST /preview-mode", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mode := strings.TrimSpace(string(body))
+		for _, m := range previewModes {
+			if m == mode {
+				togglePreviewMode(mode)
+				return
+			}
+		}
+		http.Error(w, "unknown preview mode "+mode, http.StatusBadRequest)
+	})
+	mux.HandleFunc("GET /churn", func(w http.ResponseWriter, r *http.Request) {
+		writeChurn(w, currentState().Date)
+	})
+	mux.HandleFunc("POST /filter", func(w http.ResponseWriter, r *http.Request) {
+		var req filterRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		controlMu.Lock()
+		filter := liveFilter
+		controlMu.Unlock()
+		if filter == nil {
+			http.Error(w, "no list to filter", http.StatusConflict)
+			return
+		}
+		io.WriteString(w, filter(req))
+	})
+	return mux
+}
+
+// startControlServer starts serving the state on a new socket
+func startControlServer() error {
+	dir, err := os.MkdirTemp("", "gh-shortlog-*")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "control")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		os.RemoveAll(dir)
+		return err
+	}
+	controlDir, controlPath = dir, path
+	go http.Serve(l, controlHandler())
+
+	// Don't leave the socket behind when killed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		<-signals
+		stopControlServer()
+		os.Exit(1)
+	}()
+	return nil
+}
+
+// stopControlServer removes the socket (the server ends with the process)
+func stopControlServer() {
+	if controlDir != "" {
+		os.RemoveAll(controlDir)
+		controlDir = ""
+	}
+}
+
+// exit exits with code, removing the socket first (deferred calls don't
+// run on os.Exit); everything that may exit after setup() goes through it
+func exit(code int) {
+	stopControlServer()
+	os.Exit(code)
+}
+
+// controlClient returns an HTTP client that talks to the control server at
+// path, whatever the URL's host
+func controlClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// fetchState gets the parent's state from the control server at path
+func fetchState(path string) (controlState, error) {
+	var s controlState
+	resp, err := controlClient(path).Get("http://control/state")
+	if err != nil {
+		return s, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s, fmt.Errorf("control server: %s", resp.Status)
+	}
+	err = json.NewDecoder(resp.Body).Decode(&s)
+	return s, err
+}
+
+// Subcommand: _mode
+func runModeSubcommand(args []string) {
+	path := os.Getenv("GH_SHORTLOG_SOCKET")
+	if len(args) < 1 || path == "" {
+		return
+	}
+	resp, err := controlClient(path).Post("http://control/preview-mode", "text/plain", strings.NewReader(args[0]))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	resp.Body.Close()
+}
+
+// Subcommand: _filter (with a date, or --back for the previous one)
+func runFilterSubcommand(args []string) {
+	path := os.Getenv("GH_SHORTLOG_SOCKET")
+	if len(args) < 2 || path == "" {
+		return
+	}
+
+	// fzf's {+5} is the current author if none are selected
+	var req filterRequest
+	if args[0] == "--back" {
+		req.Back = true
+	} else {
+		req.Date = args[0]
+	}
+	req.Current = args[1]
+	if os.Getenv("FZF_SELECT_COUNT") != "0" {
+		req.Selected = args[2:]
+	}
+	body, _ := json.Marshal(req)
+	resp, err := controlClient(path).Post("http://control/filter", "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+	defer resp.Body.Close()
+
+	// The response is the fzf actions, which fzf's transform runs
+	io.Copy(os.Stdout, resp.Body)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestControlServer(t *testing.T) {
+	savedArgs, savedDir, savedDate, savedMode := gitArgs, workDir, dateFilter, previewMode
+	defer func() {
+		gitArgs, workDir, dateFilter, previewMode = savedArgs, savedDir, savedDate, savedMode
+		stopControlServer()
+		controlPath = ""
+	}()
+
+	gitArgs = []string{"v1.0..v2.0", "--", "src dir/"}
+	workDir = "/some/repo"
+	previewMode = ""
+	if err := startControlServer(); err != nil {
+		t.Fatal(err)
+	}
+	setDateFilter("3 months ago")
+
+	state, err := fetchState(controlPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state.Args, gitArgs) || state.Dir != workDir || state.Date != "3 months ago" {
+		t.Errorf("state = %+v", state)
+	}
+
+	// Preview modes toggle, as the fzf bindings do with _mode
+	t.Setenv("GH_SHORTLOG_SOCKET", controlPath)
+	for _, step := range []struct {
+		toggle string
+		want   string
+	}{
+		{"help", "help"},
+		{"prs", "prs"}, // Switches straight from help to PRs
+		{"prs", ""},
+		{"punchcard", "punchcard"},
+		{"bogus", "punchcard"}, // Unknown modes are ignored
+		{"punchcard", ""},
+	} {
+		runModeSubcommand([]string{step.toggle})
+		if got := currentState().PreviewMode; got != step.want {
+			t.Errorf("after toggling %s: mode = %q, want %q", step.toggle, got, step.want)
+		}
+	}
+
+	// Date filters go to the list being shown, if any
+	client := controlClient(controlPath)
+	post := func() (int, string) {
+		resp, err := client.Post("http://control/filter", "application/json",
+			strings.NewReader(`{"date":"1 week ago","current":"<a@example.com>","selected":["<b@example.com>"]}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if status, _ := post(); status != http.StatusConflict {
+		t.Errorf("filter without a list: status %d, want %d", status, http.StatusConflict)
+	}
+	setLiveFilter(func(req filterRequest) string {
+		return req.Date + "|" + req.Current + "|" + strings.Join(req.Selected, ",")
+	})
+	defer setLiveFilter(nil)
+	if status, body := post(); status != http.StatusOK || body != "1 week ago|<a@example.com>|<b@example.com>" {
+		t.Errorf("filter: status %d, body %q", status, body)
+	}
+
+	// Nothing is left behind
+	dir := controlDir
+	stopControlServer()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s still exists", dir)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datePreset is a date filter offered by the date picker
+type datePreset struct {
+	label string
+	date  string // As given to --since; empty for the full history
+}
+
+// Dates that start like 2024-03-15, which git takes apart loosely
+var isoDateRe = regexp.MustCompile(`^\s*\d{4}-\d{1,2}-\d{1,2}`)
+
+// Words in a date, which git skips if it doesn't know them
+var dateWordRe = regexp.MustCompile(`[A-Za-z]+`)
+
+// checkDate reports whether git understands date as a --since date. git log
+// takes anything (a date it can't parse is just now), so the date is parsed
+// the strict way git parses expiry dates, e.g. gc.pruneExpire. That still
+// skips words it doesn't know and makes do with impossible dates, so those
+// are checked too.
+func checkDate(date string) error {
+	bad := fmt.Errorf("%q isn't a date git understands", date)
+
+	// Only expiry dates have these, for no limit at all
+	switch strings.ToLower(strings.TrimSpace(date)) {
+	case "never", "all", "now":
+		return bad
+	}
+	if iso := isoDateRe.FindString(date); iso != "" {
+		if _, err := time.Parse("2006-1-2", strings.TrimSpace(iso)); err != nil {
+			return bad
+		}
+	}
+	when, err := expiryDate(date)
+	if err != nil {
+		return bad
+	}
+
+	// A word that git took must change the date ("3 monthz ago" is the 3rd
+	// of this month), but "ago" never does
+	for _, loc := range dateWordRe.FindAllStringIndex(date, -1) {
+		if strings.EqualFold(date[loc[0]:loc[1]], "ago") {
+			continue
+		}
+		without, err := expiryDate(date[:loc[0]] + " " + date[loc[1]:])
+		// A second apart is just git's clock moving on between the two
+		if err == nil && without >= when-1 && without <= when+1 {
+			return bad
+		}
+	}
+	return nil
+}
+
+// expiryDate returns the Unix time that git parses date as, as an expiry date
+func expiryDate(date string) (int64, error) {
+	out, err := gitCommand("-c", "gh-shortlog.since="+date, "config", "--type=expiry-date", "gh-shortlog.since").Output()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+}
+
+// datePresets returns the date filters the picker offers at now: the last
+// week and month, this quarter, since the latest release (if there's a
+// release tag) and the full history
+func datePresets(now time.Time, releases []release) []datePreset {
+	quarter := time.Date(now.Year(), (now.Month()-1)/3*3+1, 1, 0, 0, 0, 0, now.Location())
+	presets := []datePreset{
+		{"Last week", "1 week ago"},
+		{"Last month", "1 month ago"},
+		{"This quarter", quarter.Format("2006-01-02")},
+	}
+	for i := len(releases) - 1; i >= 0; i-- {
+		if r := releases[i]; r.tag != "HEAD" {
+			presets = append(presets, datePreset{"Since " + r.tag, r.date})
+			break
+		}
+	}
+	return append(presets, datePreset{"Full history", ""})
+}
+
+// pickDate shows the date presets and returns the date filter chosen
+func pickDate() (string, bool) {
+	releases, _ := listReleases(tagPattern)
+	presets := datePresets(time.Now(), releases)
+
+	maxLabel := 0
+	for _, p := range presets {
+		maxLabel = max(maxLabel, len(p.label))
+	}
+	c := choice{
+		header: "Filter by date  │  Enter: show the authors since then  │  Esc: back",
+		prompt: "Since",
+	}
+	for _, p := range presets {
+		c.items = append(c.items, fmt.Sprintf("%s%-*s%s  %s%s%s",
+			colorWhite, maxLabel, p.label, colorReset, colorCyan, p.date, colorReset))
+	}
+
+	i, ok := ui.choose(c)
+	if !ok {
+		return "", false
+	}
+	return presets[i].date, true
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os/exec"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDatePresets(t *testing.T) {
+	now := time.Date(2024, 8, 20, 15, 4, 5, 0, time.UTC)
+	releases := []release{
+		{tag: "v1.0.0", date: "2024-01-10"},
+		{tag: "v1.1.0", date: "2024-06-02"},
+		{tag: "HEAD", date: "unreleased"},
+	}
+
+	want := []datePreset{
+		{"Last week", "1 week ago"},
+		{"Last month", "1 month ago"},
+		{"This quarter", "2024-07-01"},
+		{"Since v1.1.0", "2024-06-02"},
+		{"Full history", ""},
+	}
+	if got := datePresets(now, releases); !reflect.DeepEqual(got, want) {
+		t.Errorf("datePresets = %v, want %v", got, want)
+	}
+
+	// Without release tags; January starts the first quarter
+	got := datePresets(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), nil)
+	if len(got) != 4 || got[2].date != "2024-01-01" {
+		t.Errorf("datePresets without releases = %v", got)
+	}
+}
+
+func TestCheckDate(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	for _, date := range []string{"3 months ago", "last week", "2024-03-05", "yesterday",
+		"1.year.ago", "2 weeks", "last friday", "2024-03-05 12:00", "noon"} {
+		if err := checkDate(date); err != nil {
+			t.Errorf("checkDate(%q): %v", date, err)
+		}
+	}
+	for _, date := range []string{"fooblah", "not a date", "never", "all", "now", "Never",
+		"2024-13-45", "2024-02-30", "3 monthz ago", "zzz 3 months ago"} {
+		if err := checkDate(date); err == nil {
+			t.Errorf("checkDate(%q): expected an error", date)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Kinds of forge (code hosting service) that commit/author URLs can be built for
+const (
+	forgeGitHub    = "github"
+	forgeGitLab    = "gitlab"
+	forgeGitea     = "gitea" // Also Forgejo (e.g. Codeberg)
+	forgeBitbucket = "bitbucket"
+)
+
+// forgeRepo is a repository on a forge, as parsed from a git remote URL
+type forgeRepo struct {
+	kind string // One of the forge* constants
+	host string // e.g. "github.com", "gitlab.example.com"
+	path string // "org/repo", or "group/subgroup/repo" for GitLab
+}
+
+// Handles: git@host:path.git, ssh://git@host:22/path.git, https://host/path.git
+var remoteURLRe = regexp.MustCompile(`^(?:[a-z+]+://)?(?:[^@/]+@)?([^/:]+)(?::\d+)?[:/](.+?)(?:\.git)?/?$`)
+
+// parseRemoteURL parses a git remote URL; forgeKind overrides host-based
+// detection of the forge (for self-hosted instances with arbitrary names)
+func parseRemoteURL(remoteURL, forgeKind string) (forgeRepo, bool) {
+	remoteURL = strings.TrimSpace(remoteURL)
+	if localRemote(remoteURL) {
+		return forgeRepo{}, false
+	}
+	matches := remoteURLRe.FindStringSubmatch(remoteURL)
+	if len(matches) < 3 {
+		return forgeRepo{}, false
+	}
+	host := strings.ToLower(matches[1])
+	path := strings.Trim(matches[2], "/")
+
+	kind := forgeKind
+	if kind == "" {
+		kind = detectForge(host)
+	}
+
+	// Only GitLab has nested groups; elsewhere, the last two segments are
+	// the org and repo (any leading ones are e.g. a path prefix on the server)
+	segments := strings.Split(path, "/")
+	if len(segments) < 2 {
+		return forgeRepo{}, false
+	}
+	if kind != forgeGitLab {
+		path = strings.Join(segments[len(segments)-2:], "/")
+	}
+
+	return forgeRepo{kind: kind, host: host, path: path}, true
+}
+
+// localRemote reports whether a remote URL is a repository on this machine
+// (file://, or a path), which isn't on any forge. As git has it, a URL
+// without a scheme is scp-like (host:path) only if there's a colon before
+// any slash, and a drive letter (C:) isn't a host.
+func localRemote(remoteURL string) bool {
+	if scheme, _, ok := strings.Cut(remoteURL, "://"); ok {
+		return scheme == "file"
+	}
+	colon := strings.Index(remoteURL, ":")
+	slash := strings.IndexAny(remoteURL, `/\`)
+	return colon < 0 || (slash >= 0 && slash < colon) || colon == 1
+}
+
+// detectForge guesses the kind of forge from a host name; anything that
+// isn't recognized is assumed to be GitHub (Enterprise)
+func detectForge(host string) string {
+	switch {
+	case host == "github.com":
+		return forgeGitHub
+	case host == "gitlab.com", strings.Contains(host, "gitlab"):
+		return forgeGitLab
+	case host == "bitbucket.org", strings.Contains(host, "bitbucket"):
+		return forgeBitbucket
+	case host == "codeberg.org", host == "gitea.com",
+		strings.Contains(host, "gitea"), strings.Contains(host, "forgejo"):
+		return forgeGitea
+	default:
+		return forgeGitHub
+	}
+}
+
+// webURL is the repository's home page
+func (r forgeRepo) webURL() string {
+	return "https://" + r.host + "/" + r.path
+}
+
+// commitURLBase is the URL that a commit hash gets appended to (after a /)
+func (r forgeRepo) commitURLBase() string {
+	switch r.kind {
+	case forgeGitLab:
+		return r.webURL() + "/-/commit"
+	case forgeBitbucket:
+		return r.webURL() + "/commits"
+	default:
+		return r.webURL() + "/commit"
+	}
+}
+
+// authorURL is the page listing commits on ref by author (a login for
+// GitHub, otherwise a name or email), optionally only those after since
+// (an ISO 8601 date, only supported by GitHub)
+func (r forgeRepo) authorURL(ref, author, since string) string {
+	switch r.kind {
+	case forgeGitLab:
+		return r.webURL() + "/-/commits/" + url.PathEscape(ref) + "?author=" + url.QueryEscape(author)
+	case forgeGitea:
+		return r.webURL() + "/commits/branch/" + url.PathEscape(ref) + "/search?q=" + url.QueryEscape(author) + "&all=true"
+	case forgeBitbucket:
+		return r.webURL() + "/commits/branch/" + url.PathEscape(ref) + "?search=" + url.QueryEscape(author)
+	default:
+		u := r.webURL() + "/commits?author=" + url.QueryEscape(author)
+		if since != "" {
+			u += "&since=" + since
+		}
+		return u
+	}
+}
+
+// pullURL is the page of pull request (or GitLab merge request) number n
+func (r forgeRepo) pullURL(n int) string {
+	num := strconv.Itoa(n)
+	switch r.kind {
+	case forgeGitLab:
+		return r.webURL() + "/-/merge_requests/" + num
+	case forgeGitea:
+		return r.webURL() + "/pulls/" + num
+	case forgeBitbucket:
+		return r.webURL() + "/pull-requests/" + num
+	default:
+		return r.webURL() + "/pull/" + num
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import "testing"
+
+func TestParseRemoteURLForges(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		forgeKind string
+		want      forgeRepo
+	}{
+		{
+			name: "GitLab nested groups over SSH",
+			url:  "git@gitlab.com:group/subgroup/project.git",
+			want: forgeRepo{forgeGitLab, "gitlab.com", "group/subgroup/project"},
+		},
+		{
+			name: "self-hosted GitLab over HTTPS",
+			url:  "https://gitlab.example.com/platform/tools/cli",
+			want: forgeRepo{forgeGitLab, "gitlab.example.com", "platform/tools/cli"},
+		},
+		{
+			name: "SSH URL with port",
+			url:  "ssh://git@gitlab.example.com:2222/group/project.git",
+			want: forgeRepo{forgeGitLab, "gitlab.example.com", "group/project"},
+		},
+		{
+			name: "Codeberg",
+			url:  "https://codeberg.org/forgejo/forgejo.git",
+			want: forgeRepo{forgeGitea, "codeberg.org", "forgejo/forgejo"},
+		},
+		{
+			name: "Bitbucket",
+			url:  "git@bitbucket.org:workspace/repo.git",
+			want: forgeRepo{forgeBitbucket, "bitbucket.org", "workspace/repo"},
+		},
+		{
+			name: "GitHub Enterprise",
+			url:  "https://github.example.com/team/project.git",
+			want: forgeRepo{forgeGitHub, "github.example.com", "team/project"},
+		},
+		{
+			name:      "configured forge kind",
+			url:       "git@code.example.com:group/subgroup/project.git",
+			forgeKind: forgeGitLab,
+			want:      forgeRepo{forgeGitLab, "code.example.com", "group/subgroup/project"},
+		},
+		{
+			name: "path prefix outside GitLab",
+			url:  "https://git.example.com/scm/team/project.git",
+			want: forgeRepo{forgeGitHub, "git.example.com", "team/project"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseRemoteURL(tt.url, tt.forgeKind)
+			if !ok {
+				t.Fatalf("parseRemoteURL(%q) failed", tt.url)
+			}
+			if got != tt.want {
+				t.Errorf("parseRemoteURL(%q) = %+v, want %+v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRemoteURLLocalPaths(t *testing.T) {
+	for _, url := range []string{
+		"/srv/git/repo.git",
+		"../repo",
+		"../group/repo.git",
+		"./mirrors/group/repo",
+		"repo",
+		"mirrors/group/repo.git",
+		"file:///srv/mirrors/app.git",
+		"file://localhost/srv/mirrors/app.git",
+		`C:\repos\group\app.git`,
+		"C:/repos/group/app.git",
+	} {
+		if repo, ok := parseRemoteURL(url, ""); ok {
+			t.Errorf("parseRemoteURL(%q) = %+v, expected no match", url, repo)
+		}
+	}
+}
+
+func TestForgeURLs(t *testing.T) {
+	tests := []struct {
+		repo       forgeRepo
+		wantCommit string
+		wantAuthor string
+		wantPull   string
+	}{
+		{
+			repo:       forgeRepo{forgeGitHub, "github.com", "org/repo"},
+			wantCommit: "https://github.com/org/repo/commit",
+			wantAuthor: "https://github.com/org/repo/commits?author=octocat&since=2024-01-01T00:00:00Z",
+			wantPull:   "https://github.com/org/repo/pull/9",
+		},
+		{
+			repo:       forgeRepo{forgeGitLab, "gitlab.com", "group/sub/repo"},
+			wantCommit: "https://gitlab.com/group/sub/repo/-/commit",
+			wantAuthor: "https://gitlab.com/group/sub/repo/-/commits/main?author=octocat",
+			wantPull:   "https://gitlab.com/group/sub/repo/-/merge_requests/9",
+		},
+		{
+			repo:       forgeRepo{forgeGitea, "codeberg.org", "org/repo"},
+			wantCommit: "https://codeberg.org/org/repo/commit",
+			wantAuthor: "https://codeberg.org/org/repo/commits/branch/main/search?q=octocat&all=true",
+			wantPull:   "https://codeberg.org/org/repo/pulls/9",
+		},
+		{
+			repo:       forgeRepo{forgeBitbucket, "bitbucket.org", "ws/repo"},
+			wantCommit: "https://bitbucket.org/ws/repo/commits",
+			wantAuthor: "https://bitbucket.org/ws/repo/commits/branch/main?search=octocat",
+			wantPull:   "https://bitbucket.org/ws/repo/pull-requests/9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.repo.kind, func(t *testing.T) {
+			if got := tt.repo.commitURLBase(); got != tt.wantCommit {
+				t.Errorf("commitURLBase() = %q, want %q", got, tt.wantCommit)
+			}
+			if got := tt.repo.authorURL("main", "octocat", "2024-01-01T00:00:00Z"); got != tt.wantAuthor {
+				t.Errorf("authorURL() = %q, want %q", got, tt.wantAuthor)
+			}
+			if got := tt.repo.pullURL(9); got != tt.wantPull {
+				t.Errorf("pullURL() = %q, want %q", got, tt.wantPull)
+			}
+		})
+	}
+}
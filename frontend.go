@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// frontend is what the interactive screens are shown with; runInteractive
+// and the other interactive modes keep the navigation state, and the
+// frontend only shows a screen and reports what the user did there
+type frontend interface {
+	// authors shows list, and returns the action taken, the query and the
+	// selected lines. Actions are "ctrl-o" (filter by the date in query),
+	// "back", "quit", "remote" and "accept". Frontends can also change the
+	// date filter in place, with list.filter and list.back.
+	authors(list *authorList) (action, query string, selections []string)
+
+	// choose shows a list to pick one item from and returns its index
+	choose(c choice) (int, bool)
+
+	// compare shows the comparison of two ranges, and returns the action
+	// taken ("sort" for the next sort order, "quit" to output the selected
+	// lines, or "" to exit) and the selected lines
+	compare(c comparison) (action string, selections []string)
+}
+
+// choice is a list for frontend.choose
+type choice struct {
+	header  string
+	prompt  string
+	items   []string // Lines to show (may be colorized)
+	preview string   // Subcommand showing an item's details, if any...
+	keys    []string // ...given the item's key as its argument
+}
+
+// comparison is a screen for frontend.compare
+type comparison struct {
+	ranges [2]string
+	sortBy string   // One of compareSorts
+	lines  []string // One per author (colorized), as formatCompare renders them
+}
+
+// Column titles above comparison lines
+var compareColumns = fmt.Sprintf("%6s  %6s  %6s  Author", "first", "second", "change")
+
+// header describes the comparison, for the screens that can re-sort it
+func (c comparison) header() string {
+	return fmt.Sprintf("%s%s  vs.  %s%s  │  Sorted by %s (^S: change)\n%s%s%s",
+		colorYellow, c.ranges[0], c.ranges[1], colorReset, compareSortNames[c.sortBy],
+		colorWhite, compareColumns, colorReset)
+}
+
+// Frontends that --ui can name
+var frontends = map[string]func() frontend{
+	"fzf":     func() frontend { return fzfFrontend{} },
+	"builtin": func() frontend { return builtinFrontend{} },
+	"prompt":  func() frontend { return newPromptFrontend() },
+}
+
+var (
+	uiName string   // Frontend requested with --ui
+	ui     frontend // Frontend in use
+)
+
+// selectFrontend returns the frontend named by --ui; by default, fzf if
+// it's installed, or else the builtin one (which itself falls back to the
+// line-oriented prompt if there's no terminal)
+func selectFrontend(name string) (frontend, error) {
+	if name == "" {
+		if _, err := exec.LookPath("fzf"); err == nil {
+			return fzfFrontend{}, nil
+		}
+		return builtinFrontend{}, nil
+	}
+	newFrontend, ok := frontends[name]
+	if !ok {
+		var names []string
+		for n := range frontends {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown UI %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	return newFrontend(), nil
+}
+
+// fzfFrontend shows the screens with fzf
+type fzfFrontend struct{}
+
+// The first fzf version with what updating the list while it's shown
+// needs: --listen (for streaming), and transform() and change-header (for
+// filtering in place)
+var fzfLiveVersion = [3]int{0, 45, 0}
+
+// fzfLive reports whether the installed fzf can update the list while it's
+// shown; older ones get the list on stdin, and are restarted for changes
+var fzfLive = sync.OnceValue(func() bool {
+	out, err := exec.Command("fzf", "--version").Output()
+	if err != nil {
+		return false
+	}
+	v, ok := parseFzfVersion(string(out))
+	return ok && slices.Compare(v[:], fzfLiveVersion[:]) >= 0
+})
+
+// parseFzfVersion parses fzf --version output, e.g. "0.56.3 (brew)"
+func parseFzfVersion(out string) ([3]int, bool) {
+	var v [3]int
+	fields := strings.Fields(out)
+	if len(fields) == 0 {
+		return v, false
+	}
+	parts := strings.Split(fields[0], ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
+
+func (fzfFrontend) authors(list *authorList) (string, string, []string) {
+	return launchFzf(list)
+}
+
+func (fzfFrontend) choose(c choice) (int, bool) {
+	// Each line is "index<TAB>key<TAB>item", with only the item shown
+	var input strings.Builder
+	for i, item := range c.items {
+		key := ""
+		if i < len(c.keys) {
+			key = c.keys[i]
+		}
+		fmt.Fprintf(&input, "%d\t%s\t%s\n", i, key, item)
+	}
+
+	fzfArgs := []string{
+		"--ansi",
+		"--delimiter", "\t",
+		"--with-nth", "3..",
+		"--border=rounded",
+		"--layout=reverse",
+		"--no-multi",
+		"--no-sort",
+		"--header", colorYellow + c.header + colorReset,
+		"--prompt", c.prompt + " > ",
+		"--color", "fg:15,bg:-1,hl:1",
+		"--color", "header:green:italic",
+		"--color", "prompt:80,info:40",
+		"--color", "border:dim",
+	}
+	if c.preview != "" {
+		fzfArgs = append(fzfArgs,
+			"--preview-window=border-line",
+			"--preview", shellQuote(selfPath)+" "+c.preview+" {2}",
+			"--bind", "ctrl-b:preview-page-up,ctrl-f:preview-page-down")
+	}
+	if noMouse {
+		fzfArgs = append(fzfArgs, "--no-mouse")
+	}
+
+	cmd := exec.Command("fzf", fzfArgs...)
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stderr = os.Stderr
+	cmd.Env = subcommandEnv()
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, false
+	}
+
+	index, _, _ := strings.Cut(string(out), "\t")
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 || i >= len(c.items) {
+		return 0, false
+	}
+	return i, true
+}
+
+func (fzfFrontend) compare(c comparison) (string, []string) {
+	key, selections := launchCompareFzf(c)
+	switch key {
+	case "ctrl-s":
+		return "sort", nil
+	case "ctrl-q":
+		return "quit", selections
+	}
+	return "", nil
+}
+
+// Fields of an author list line, as fzf splits them with --delimiter " {2,}"
+var listFieldRe = regexp.MustCompile(` {2,}`)
+
+// lineAuthor returns the name and email of an author list line
+func lineAuthor(line string) string {
+	fields := listFieldRe.Split(stripANSI(line), -1)
+	if len(fields) < 5 {
+		return ""
+	}
+	return fields[3] + " " + strings.TrimSpace(fields[4])
+}
+
+// lineEmail returns the email (fzf's {5}) of an author list line
+func lineEmail(line string) string {
+	fields := listFieldRe.Split(stripANSI(line), -1)
+	if len(fields) < 5 {
+		return ""
+	}
+	return strings.TrimSpace(fields[4])
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSelectFrontend(t *testing.T) {
+	if f, err := selectFrontend("fzf"); err != nil || !reflect.DeepEqual(f, fzfFrontend{}) {
+		t.Errorf("selectFrontend(fzf) = %T, %v", f, err)
+	}
+	if f, err := selectFrontend("prompt"); err != nil {
+		t.Errorf("selectFrontend(prompt): %v", err)
+	} else if _, ok := f.(*promptFrontend); !ok {
+		t.Errorf("selectFrontend(prompt) = %T", f)
+	}
+	if f, err := selectFrontend("builtin"); err != nil || f != (builtinFrontend{}) {
+		t.Errorf("selectFrontend(builtin) = %T, %v", f, err)
+	}
+	if _, err := selectFrontend("curses"); err == nil || !strings.Contains(err.Error(), "builtin, fzf, prompt") {
+		t.Errorf("expected an error listing the frontends, got %v", err)
+	}
+}
+
+func TestLineEmail(t *testing.T) {
+	line := formatEntries([]shortlogEntry{{12, "Jane Smith", "<jane@example.com>"}})
+	if got := lineEmail(strings.TrimSuffix(line, "\n")); got != "<jane@example.com>" {
+		t.Errorf("lineEmail = %q, want <jane@example.com>", got)
+	}
+	if got := lineAuthor(line); got != "Jane Smith <jane@example.com>" {
+		t.Errorf("lineAuthor = %q, want Jane Smith <jane@example.com>", got)
+	}
+	if got := lineEmail("garbage"); got != "" {
+		t.Errorf("lineEmail(garbage) = %q, want empty", got)
+	}
+}
+
+func testPrompt(input string) *promptFrontend {
+	return &promptFrontend{in: bufio.NewReader(strings.NewReader(input)), out: io.Discard}
+}
+
+func TestPromptAuthors(t *testing.T) {
+	input := formatEntries([]shortlogEntry{
+		{12, "Jane Smith", "<jane@example.com>"},
+		{3, "John Doe", "<john@example.com>"},
+	})
+
+	tests := []struct {
+		commands   string
+		action     string
+		query      string
+		selections int
+	}{
+		{"s 1 2\ns 1\nq\n", "quit", "", 1},
+		{"f 3 months ago\n", "ctrl-o", "3 months ago", 0},
+		{"f not a date\nf\n", "dates", "", 0}, // Invalid dates are refused
+		{"\n/jane\nx\n9\nb\n", "back", "", 0}, // Blank lines and bad input are ignored
+		{"", "back", "", 0},                   // End of input
+	}
+	for _, tt := range tests {
+		action, query, selections := testPrompt(tt.commands).authors(&authorList{input: input})
+		if action != tt.action || query != tt.query || len(selections) != tt.selections {
+			t.Errorf("%q: got %q, %q, %q; want %q, %q, %d selections",
+				tt.commands, action, query, selections, tt.action, tt.query, tt.selections)
+		}
+	}
+
+	_, _, selections := testPrompt("s 2\nq\n").authors(&authorList{input: input})
+	if len(selections) != 1 || !strings.Contains(selections[0], "John Doe") || strings.Contains(selections[0], "\033") {
+		t.Errorf("selections = %q, want John's line without colors", selections)
+	}
+}
+
+func TestPromptChoose(t *testing.T) {
+	c := choice{header: "Pick", prompt: "Item", items: []string{"one", "two", "three"}}
+	if i, ok := testPrompt("0\n2\n").choose(c); !ok || i != 1 {
+		t.Errorf("choose = %d, %v; want 1, true", i, ok)
+	}
+	if _, ok := testPrompt("b\n").choose(c); ok {
+		t.Error("expected no choice after b")
+	}
+	if _, ok := testPrompt("").choose(c); ok {
+		t.Error("expected no choice at end of input")
+	}
+}
+
+func TestParseFzfVersion(t *testing.T) {
+	tests := []struct {
+		out  string
+		want [3]int
+		ok   bool
+	}{
+		{"0.56.3 (brew)\n", [3]int{0, 56, 3}, true},
+		{"0.44.1 (debian)", [3]int{0, 44, 1}, true},
+		{"0.29.0\n", [3]int{0, 29, 0}, true},
+		{"0.45", [3]int{0, 45, 0}, true},
+		{"", [3]int{}, false},
+		{"fzf: unknown option", [3]int{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseFzfVersion(tt.out)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseFzfVersion(%q) = %v, %v; want %v, %v", tt.out, got, ok, tt.want, tt.ok)
+		}
+	}
+}
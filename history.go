@@ -0,0 +1,99 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sideshowbarker/gh-shortlog/shortlog"
+)
+
+// historyCommit is a commit as read by readHistory
+type historyCommit struct {
+	sha     string
+	name    string // Author name, after .mailmap mapping
+	email   string // Author email, with <>, after .mailmap mapping
+	when    time.Time
+	subject string
+	files   []fileChange // Only if readHistory was asked for them
+}
+
+// fileChange is one file's line counts in a commit (0/0 for binary files)
+type fileChange struct {
+	path    string
+	added   int
+	deleted int
+}
+
+// Format for parseHistory: each record starts with RS, fields separated by US
+const historyFormat = shortlog.LogFormat
+
+// readHistory returns the commits (newest first) in the range given by
+// gitArgs (less the shortlog-only options), optionally only those after
+// sinceDate, and with per-file line counts if withFiles is set
+func readHistory(sinceDate string, withFiles bool) ([]historyCommit, error) {
+	return readHistoryOf(gitArgs, sinceDate, withFiles)
+}
+
+// readHistoryOf is readHistory for the range given by args instead
+func readHistoryOf(args []string, sinceDate string, withFiles bool) ([]historyCommit, error) {
+	query := gitQuery(sinceDate)
+	query.Args = historyArgs(args)
+	query.PathsOnly = clone.missingContents() && !deepen
+	commits, err := query.Commits(withFiles)
+	if err != nil {
+		return nil, err
+	}
+	result := historyCommits(commits)
+
+	// Without line counts, each file changed counts as one line, so that
+	// authors and directories are still weighed by how much they changed
+	if query.PathsOnly {
+		for _, c := range result {
+			for i := range c.files {
+				c.files[i].added = 1
+			}
+		}
+	}
+	return result, nil
+}
+
+// parseHistory parses git log output in historyFormat (with --numstat lines)
+func parseHistory(out string) []historyCommit {
+	return historyCommits(shortlog.ParseLog(out))
+}
+
+// historyCommits converts the shortlog package's commits
+func historyCommits(commits []shortlog.Commit) []historyCommit {
+	var result []historyCommit
+	for _, c := range commits {
+		h := historyCommit{sha: c.SHA, name: c.Name, email: c.Email, when: c.When, subject: c.Subject}
+		for _, f := range c.Files {
+			h.files = append(h.files, fileChange{f.Path, f.Added, f.Deleted})
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// authorKey identifies an author the way git shortlog -e does
+func authorKey(c historyCommit) string {
+	return c.name + " " + c.email
+}
+
+// historyEntries counts commits per author, in git shortlog -n order
+func historyEntries(commits []historyCommit) []shortlogEntry {
+	counts := make(map[string]*shortlogEntry)
+	for _, c := range commits {
+		e, ok := counts[authorKey(c)]
+		if !ok {
+			e = &shortlogEntry{name: c.name, email: c.email}
+			counts[authorKey(c)] = e
+		}
+		e.count++
+	}
+	var entries []shortlogEntry
+	for _, e := range counts {
+		entries = append(entries, *e)
+	}
+	sortEntries(entries)
+	return entries
+}
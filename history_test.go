@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseHistory(t *testing.T) {
+	out := "\x1eaaa\x1fJane Smith\x1fjane@example.com\x1f2024-03-05T10:00:00+01:00\x1fAdd parser\n\n" +
+		"10\t2\tsrc/parser.go\n" +
+		"-\t-\tassets/logo.png\n" +
+		"\x1ebbb\x1fJohn Doe\x1fjohn@example.com\x1f2024-02-01T09:30:00Z\x1fInitial commit\n"
+
+	commits := parseHistory(out)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+
+	c := commits[0]
+	if c.sha != "aaa" || c.name != "Jane Smith" || c.email != "<jane@example.com>" || c.subject != "Add parser" {
+		t.Errorf("unexpected commit: %+v", c)
+	}
+	if want := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC); !c.when.Equal(want) {
+		t.Errorf("when = %v, want %v", c.when, want)
+	}
+	if len(c.files) != 2 || c.files[0] != (fileChange{"src/parser.go", 10, 2}) || c.files[1] != (fileChange{"assets/logo.png", 0, 0}) {
+		t.Errorf("files = %+v", c.files)
+	}
+	if len(commits[1].files) != 0 {
+		t.Errorf("expected no files for second commit, got %+v", commits[1].files)
+	}
+}
+
+func TestHistoryEntries(t *testing.T) {
+	commits := []historyCommit{
+		{name: "John", email: "<john@example.com>"},
+		{name: "Jane", email: "<jane@example.com>"},
+		{name: "Jane", email: "<jane@example.com>"},
+		{name: "Ann", email: "<ann@example.com>"},
+	}
+	got := historyEntries(commits)
+	want := []shortlogEntry{
+		{2, "Jane", "<jane@example.com>"},
+		{1, "Ann", "<ann@example.com>"},
+		{1, "John", "<john@example.com>"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("historyEntries() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// How many authors to look up per GraphQL request
+const loginBatchSize = 50
+
+// loginCache is a persistent email→GitHub login map, so resolved logins
+// can be shown in the list and used by ^W without going to the network.
+// An empty login records an email that GitHub didn't know about.
+// The cache file holds one such map per GitHub host (github.com or a
+// GitHub Enterprise host); a loginCache is the map for one of them.
+type loginCache struct {
+	mu     sync.Mutex
+	path   string
+	host   string
+	logins map[string]string
+}
+
+// Shared cache; nil if the user cache dir isn't available
+var logins *loginCache
+
+// loadLoginCache reads the logins for the current forge host from the
+// cache file in the user cache dir
+func loadLoginCache() *loginCache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	c := &loginCache{
+		path:   filepath.Join(dir, "gh-shortlog", "logins.json"),
+		host:   githubHost(),
+		logins: make(map[string]string),
+	}
+	if hosts, err := c.readFile(); err == nil && hosts[c.host] != nil {
+		c.logins = hosts[c.host]
+	}
+	return c
+}
+
+// readFile reads the logins for all hosts from the cache file
+func (c *loginCache) readFile() (map[string]map[string]string, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	hosts := make(map[string]map[string]string)
+	if err := json.Unmarshal(data, &hosts); err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// lookup returns the cached login for email, and whether it was cached at all
+func (c *loginCache) lookup(email string) (login string, ok bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	login, ok = c.logins[bareEmail(email)]
+	return login, ok
+}
+
+// store records logins (keyed by email) and writes the cache file
+func (c *loginCache) store(found map[string]string) error {
+	if c == nil || len(found) == 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Merge with what's on disk, in case another process updated it
+	hosts, err := c.readFile()
+	if err != nil {
+		hosts = make(map[string]map[string]string)
+	}
+	for email, login := range hosts[c.host] {
+		if _, ok := c.logins[email]; !ok {
+			c.logins[email] = login
+		}
+	}
+	for email, login := range found {
+		c.logins[bareEmail(email)] = login
+	}
+	hosts[c.host] = c.logins
+
+	data, err := json.MarshalIndent(hosts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
+
+// writeFileAtomic writes data to a scratch file and renames it to path (creating
+// its directory if needed), so other processes never read a partial file. Each
+// writer has its own scratch file, as previews (processes, or goroutines in the
+// builtin UI) write at the same time.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Fails once it's renamed
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// bareEmail strips the <> that git shortlog -e puts around emails
+func bareEmail(email string) string {
+	return strings.ToLower(strings.Trim(email, "<>"))
+}
+
+// noreplyLogin extracts the login from a GitHub noreply address
+// (12345+username@users.noreply.github.com or username@users.noreply.github.com)
+func noreplyLogin(email string) string {
+	re := regexp.MustCompile(`^(?:\d+\+)?([^@+]+)@users\.noreply\.github\.com$`)
+	if matches := re.FindStringSubmatch(bareEmail(email)); len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
+// resolveLogins looks up GitHub logins for all emails not yet in the cache,
+// using batched GraphQL queries against the commit history of orgAndRepo
+func resolveLogins(emails []string) error {
+	if logins == nil || orgAndRepo == "" || forgeKind != forgeGitHub {
+		return nil
+	}
+
+	found := make(map[string]string)
+	var pending []string
+	seen := make(map[string]bool)
+	for _, email := range emails {
+		email = bareEmail(email)
+		if seen[email] {
+			continue
+		}
+		seen[email] = true
+		if _, ok := logins.lookup(email); ok {
+			continue
+		}
+		if login := noreplyLogin(email); login != "" {
+			found[email] = login
+			continue
+		}
+		pending = append(pending, email)
+	}
+
+	var err error
+	for start := 0; start < len(pending); start += loginBatchSize {
+		end := min(start+loginBatchSize, len(pending))
+		var batch map[string]string
+		if batch, err = queryLogins(pending[start:end]); err != nil {
+			break
+		}
+		for email, login := range batch {
+			found[email] = login
+		}
+	}
+
+	if storeErr := logins.store(found); err == nil {
+		err = storeErr
+	}
+	return err
+}
+
+// loginQuery builds a GraphQL query with one aliased commit-history lookup
+// per email, so a whole batch of authors is resolved in a single request
+func loginQuery(emails []string) string {
+	var q strings.Builder
+	q.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) { defaultBranchRef { target { ... on Commit {")
+	for i, email := range emails {
+		fmt.Fprintf(&q, " a%d: history(first: 1, author: {emails: [%s]}) { nodes { author { user { login } } } }",
+			i, strconv.Quote(email))
+	}
+	q.WriteString(" } } } } }")
+	return q.String()
+}
+
+// queryLogins runs loginQuery via gh; emails without a GitHub user map to ""
+func queryLogins(emails []string) (map[string]string, error) {
+	org, repo, _ := strings.Cut(orgAndRepo, "/")
+	cmd := ghAPICommand("graphql",
+		"-f", "query="+loginQuery(emails),
+		"-f", "owner="+org,
+		"-f", "name="+repo)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseLoginResponse(out, emails)
+}
+
+// parseLoginResponse maps the aliased results of loginQuery back to emails
+func parseLoginResponse(data []byte, emails []string) (map[string]string, error) {
+	type history struct {
+		Nodes []struct {
+			Author struct {
+				User *struct {
+					Login string `json:"login"`
+				} `json:"user"`
+			} `json:"author"`
+		} `json:"nodes"`
+	}
+	var resp struct {
+		Data struct {
+			Repository struct {
+				DefaultBranchRef struct {
+					Target map[string]history `json:"target"`
+				} `json:"defaultBranchRef"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]string)
+	target := resp.Data.Repository.DefaultBranchRef.Target
+	for i, email := range emails {
+		login := ""
+		if h, ok := target["a"+strconv.Itoa(i)]; ok && len(h.Nodes) > 0 && h.Nodes[0].Author.User != nil {
+			login = h.Nodes[0].Author.User.Login
+		}
+		found[email] = login
+	}
+	return found, nil
+}
+
+// githubHost returns the host of the GitHub (Enterprise) instance that the
+// repo is on, defaulting to github.com
+func githubHost() string {
+	if forgeKind == forgeGitHub && forgeHost != "" {
+		return forgeHost
+	}
+	return "github.com"
+}
+
+// ghAPICommand builds a gh api command for the repo's GitHub host, so that
+// GitHub Enterprise repos are looked up on their own instance
+func ghAPICommand(args ...string) *exec.Cmd {
+	apiArgs := []string{"api"}
+	if host := githubHost(); host != "github.com" {
+		apiArgs = append(apiArgs, "--hostname", host)
+	}
+	return exec.Command("gh", append(apiArgs, args...)...)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNoreplyLogin(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"<12345+octocat@users.noreply.github.com>", "octocat"},
+		{"octocat@users.noreply.github.com", "octocat"},
+		{"<john@example.com>", ""},
+		{"12345+john@example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			if got := noreplyLogin(tt.email); got != tt.want {
+				t.Errorf("noreplyLogin(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginQuery(t *testing.T) {
+	q := loginQuery([]string{"jane@example.com", "john@example.com"})
+
+	for _, want := range []string{
+		`a0: history(first: 1, author: {emails: ["jane@example.com"]})`,
+		`a1: history(first: 1, author: {emails: ["john@example.com"]})`,
+	} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query missing %q:\n%s", want, q)
+		}
+	}
+	if strings.Count(q, "{") != strings.Count(q, "}") {
+		t.Errorf("unbalanced braces in query:\n%s", q)
+	}
+}
+
+func TestParseLoginResponse(t *testing.T) {
+	response := `{"data": {"repository": {"defaultBranchRef": {"target": {
+		"a0": {"nodes": [{"author": {"user": {"login": "jane"}}}]},
+		"a1": {"nodes": [{"author": {"user": null}}]},
+		"a2": {"nodes": []}
+	}}}}}`
+	emails := []string{"jane@example.com", "john@example.com", "bob@example.com"}
+
+	found, err := parseLoginResponse([]byte(response), emails)
+	if err != nil {
+		t.Fatalf("parseLoginResponse: %v", err)
+	}
+
+	want := map[string]string{
+		"jane@example.com": "jane",
+		"john@example.com": "",
+		"bob@example.com":  "",
+	}
+	for email, login := range want {
+		got, ok := found[email]
+		if !ok {
+			t.Errorf("missing result for %s", email)
+		} else if got != login {
+			t.Errorf("login for %s = %q, want %q", email, got, login)
+		}
+	}
+}
+
+func TestLoginCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gh-shortlog", "logins.json")
+	c := &loginCache{path: path, host: "github.com", logins: make(map[string]string)}
+
+	if err := c.store(map[string]string{"<Jane@Example.com>": "jane", "john@example.com": ""}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	// Logins on another host are kept separately
+	enterprise := &loginCache{path: path, host: "github.example.com", logins: make(map[string]string)}
+	if err := enterprise.store(map[string]string{"jane@example.com": "jsmith"}); err != nil {
+		t.Fatalf("store: %v", err)
+	}
+
+	// A fresh cache reading the same file sees the stored logins
+	reloaded := &loginCache{path: path, host: "github.com", logins: make(map[string]string)}
+	reloaded.store(map[string]string{"bob@example.com": "bob"})
+
+	tests := []struct {
+		email     string
+		wantLogin string
+		wantOK    bool
+	}{
+		{"<jane@example.com>", "jane", true},
+		{"john@example.com", "", true},
+		{"bob@example.com", "bob", true},
+		{"nobody@example.com", "", false},
+	}
+	for _, tt := range tests {
+		login, ok := reloaded.lookup(tt.email)
+		if login != tt.wantLogin || ok != tt.wantOK {
+			t.Errorf("lookup(%q) = %q, %v; want %q, %v", tt.email, login, ok, tt.wantLogin, tt.wantOK)
+		}
+	}
+
+	if login, _ := enterprise.lookup("jane@example.com"); login != "jsmith" {
+		t.Errorf("enterprise lookup = %q, want %q", login, "jsmith")
+	}
+}
+
+func TestWriteFileAtomicConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cache", "pulls.json")
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := writeFileAtomic(path, []byte(fmt.Sprintf("writer %d\n", i))); err != nil {
+				t.Errorf("writeFileAtomic: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "writer ") {
+		t.Errorf("file = %q, %v; want one writer's data", data, err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("scratch files left behind: %v", entries)
+	}
+}
+
+func TestGHAPICommandHostname(t *testing.T) {
+	oldKind, oldHost := forgeKind, forgeHost
+	defer func() { forgeKind, forgeHost = oldKind, oldHost }()
+
+	tests := []struct {
+		kind string
+		host string
+		want string
+	}{
+		{forgeGitHub, "github.com", "gh api graphql"},
+		{forgeGitHub, "", "gh api graphql"},
+		{forgeGitHub, "github.example.com", "gh api --hostname github.example.com graphql"},
+		{forgeGitLab, "gitlab.example.com", "gh api graphql"},
+	}
+	for _, tt := range tests {
+		forgeKind, forgeHost = tt.kind, tt.host
+		cmd := ghAPICommand("graphql")
+		if got := strings.Join(append([]string{"gh"}, cmd.Args[1:]...), " "); got != tt.want {
+			t.Errorf("%s on %q: command = %q, want %q", tt.kind, tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestFormatEntriesLoginColumn(t *testing.T) {
+	oldLogins := logins
+	defer func() { logins = oldLogins }()
+	logins = &loginCache{
+		path:   filepath.Join(t.TempDir(), "logins.json"),
+		logins: map[string]string{"jane@example.com": "jane"},
+	}
+
+	result := formatEntries([]shortlogEntry{
+		{2, "Jane Smith", "<jane@example.com>"},
+		{1, "John Doe", "<john@example.com>"},
+	})
+	lines := strings.Split(strings.TrimSpace(result), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	if !strings.Contains(lines[0], "@jane") {
+		t.Errorf("expected @jane in %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[1], "<john@example.com>"+colorReset) {
+		t.Errorf("expected no login column for unresolved author, got %q", lines[1])
+	}
+}
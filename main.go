@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -9,18 +12,22 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/sideshowbarker/gh-shortlog/shortlog"
 )
 
 const (
 	version = "2.0.0"
 
 	// ANSI color codes
-	colorReset     = "\033[0m"
-	colorGreen     = "\033[1;32m"
-	colorWhite     = "\033[1;37m"
-	colorCyan      = "\033[0;36m"
-	colorBoldCyan  = "\033[1;36m"
-	colorYellow    = "\033[1;33m"
+	colorReset    = "\033[0m"
+	colorGreen    = "\033[1;32m"
+	colorWhite    = "\033[1;37m"
+	colorCyan     = "\033[0;36m"
+	colorBoldCyan = "\033[1;36m"
+	colorYellow   = "\033[1;33m"
+	colorRed      = "\033[1;31m"
 
 	// Help text shown in preview when ? is pressed
 	helpText = `
@@ -34,12 +41,19 @@ const (
 ` + "\033[1;33m" + `Actions` + "\033[0m" + `
   Tab               Show commits with diffs for selected author(s)
   Enter             Filter by date (type a date first, then Enter)
-  ^W                Open author's commits in GitHub browser
+  Alt-D             Choose a date filter: last week, last month, this
+                    quarter, since the latest release or full history
+  ^W                Open author's commits on GitHub/GitLab/etc.
+  ^R                Choose which remote links go to (if remotes differ)
+  Alt-P             Toggle pull requests for selected author(s)
+  Alt-H             Toggle weekday × hour punchcard of commit times
+  Alt-C             Toggle new/returning/continuing/lapsed authors,
+                    compared with the period before the date filter
 
 ` + "\033[1;33m" + `Other` + "\033[0m" + `
   ?                 Toggle this help
   ^Q                Exit and output selected items
-  ^C/Esc            Exit
+  ^C/Esc            Back to the previous date filter, or exit
 
 ` + "\033[1;33m" + `Tips` + "\033[0m" + `
   • Type to filter authors by name or email
@@ -53,17 +67,26 @@ sponsoring fzf's creator, ` + "\033[1;33m" + `Junegunn Choi` + "\033[0m" + `:
 
     ` + "\033[0;36m" + `https://github.com/sponsors/junegunn` + "\033[0m" + `
 `
+
+	// Exit status of the modes that print their result instead of starting
+	// the UI, when no commits match (errors exit with 1, usage errors with 2)
+	exitNoCommits = 3
 )
 
 // Global state
 var (
-	gitArgs     []string // Arguments to pass to git
-	workDir     string   // Working directory for git commands
-	noMouse     bool     // Disable mouse in fzf
-	dateFile    string   // Temp file for storing date filter
-	baseURL     string   // GitHub commit URL base
-	orgAndRepo  string   // GitHub org/repo
-	selfPath    string   // Path to this executable
+	gitArgs    []string // Arguments to pass to git
+	workDir    string   // Working directory for git commands
+	noMouse    bool     // Disable mouse in fzf
+	noStream   bool     // Generate the author list in one go instead of streaming it
+	dateFilter string   // Current date filter (empty for full history)
+	baseURL    string   // Forge commit URL base
+	orgAndRepo string   // Forge org/repo (or group/subgroup/repo on GitLab)
+	forgeKind  string   // Kind of forge hosting the repo (forgeGitHub, etc.)
+	forgeHost  string   // Host name of the forge
+	remoteName string   // Name of the git remote the forge info came from
+	remoteFlag string   // Remote requested with --remote
+	selfPath   string   // Path to this executable
 )
 
 func main() {
@@ -74,6 +97,8 @@ func main() {
 		selfPath = os.Args[0]
 	}
 
+	defer stopControlServer()
+
 	// Parse arguments
 	args := os.Args[1:]
 	if len(args) > 0 {
@@ -84,6 +109,14 @@ func main() {
 		case "--version", "-v":
 			fmt.Println("gh-shortlog", version)
 			return
+		case "report":
+			// Static HTML contributor report
+			runReportCommand(args[1:])
+			return
+		case "risk":
+			// Knowledge concentration per directory
+			runRiskCommand(args[1:])
+			return
 		case "_preview":
 			// Internal: preview for fzf
 			runPreviewSubcommand(args[1:])
@@ -96,15 +129,78 @@ func main() {
 			// Internal: open in browser
 			runBrowserSubcommand(args[1:])
 			return
-		case "_help":
-			// Internal: show help in preview
-			fmt.Print(helpText)
+		case "_prs":
+			// Internal: show pull requests in preview
+			runPullsSubcommand(args[1:])
+			return
+		case "_release":
+			// Internal: show a release's authors in preview
+			runReleaseSubcommand(args[1:])
+			return
+		case "_punchcard":
+			// Internal: show commit times in preview
+			runPunchcardSubcommand(args[1:])
+			return
+		case "_churn":
+			// Internal: compare the period's authors with the previous period's
+			runChurnSubcommand()
+			return
+		case "_filter":
+			// Internal: filter the list by date, in place
+			runFilterSubcommand(args[1:])
+			return
+		case "_mode":
+			// Internal: switch the preview mode
+			runModeSubcommand(args[1:])
 			return
 		}
 	}
 
-	// Main interactive mode
 	parseArgs(args)
+
+	// Chart export instead of the UI
+	if chartFormat != "" {
+		if baseURL == "" || orgAndRepo == "" {
+			setupGitHubInfo()
+		}
+		setupClone()
+		warnClone(false)
+		runChart()
+		return
+	}
+
+	// Two ranges side by side instead of the usual author list
+	if len(compareRanges) > 0 {
+		setupFrontend()
+		runCompare()
+		return
+	}
+
+	// Releases first, then the authors of the one chosen
+	if byTag {
+		setup()
+		runByTag()
+		return
+	}
+
+	// Authors × periods table instead of the UI; in the UI, --bucket adds
+	// each author's per-period series to the preview
+	if bucketTable && bucketPeriod == "" {
+		fmt.Fprintln(os.Stderr, "Error: --table needs --bucket=month|quarter|year")
+		exit(2)
+	}
+	if bucketPeriod != "" && !validPeriod(bucketPeriod) {
+		fmt.Fprintf(os.Stderr, "Error: unsupported bucket %q (supported: %s)\n", bucketPeriod, strings.Join(periodNames, ", "))
+		exit(2)
+	}
+	if bucketTable {
+		setupClone()
+		warnClone(false)
+		runBucketTable()
+		return
+	}
+
+	// Main interactive mode
 	setup()
 	runInteractive()
 }
@@ -113,12 +209,58 @@ func printHelp() {
 	fmt.Println(`gh-shortlog - Interactive git shortlog explorer
 
 Usage: gh-shortlog [options] [<revision-range>] [[--] <path>...]
+       gh-shortlog [options] <url>|gh:<owner/repo> [<revision-range>] [[--] <path>...]
+       gh-shortlog report --html <file> [options] [<revision-range>] [[--] <path>...]
+       gh-shortlog risk [options] [<revision-range>] [[--] <path>...]
+       gh-shortlog --compare <range> <range> [options] [[--] <path>...]
+       gh-shortlog --chart=svg [--chart-output=<file>] [options] [<revision-range>] [[--] <path>...]
 
 Options:
   --no-mouse    Disable mouse support in fzf
+  --no-stream   Wait for the full author list instead of showing partial counts
+  --remote NAME Build commit/author links for the given git remote
+  --git-dir=DIR Use the repository in DIR (e.g. a bare mirror), as with
+                git --git-dir
+  --deepen      In a shallow clone, fetch the rest of the history first; in
+                a partial one, let git fetch file contents for line counts
+  --ui=UI       fzf (the default, if installed), builtin (the default
+                without fzf) or prompt (line-oriented). builtin needs a
+                Unix terminal (/dev/tty and stty), so on Windows it falls
+                back to prompt
+  --compare A B Show each author's commits in revision ranges A and B side
+                by side, with the change (^S changes the sort order)
+  --punchcard-tz=ZONE
+                Show the Alt-H punchcard in ZONE (e.g. UTC, Europe/Berlin,
+                local) instead of each author's own time zone
+  --by-tag      List the releases (version-sorted tags), with each one's
+                authors in the preview; Enter shows a release's authors
+    --tag-pattern=GLOB     Only tags matching GLOB (e.g. 'v2.*')
+  --chart=svg   Write a stacked chart of commits per period for the top
+                authors instead of starting the UI
+    --chart-output=FILE    Chart file (default: stdout)
+    --chart-top=N          Authors shown separately; the rest are "Others"
+                           (default: 10)
+    --chart-period=PERIOD  week, month (default), quarter or year
+    --chart-style=STYLE    bar (default) or area
+  --bucket=PERIOD
+                Count commits per week, month, quarter or year; the preview
+                starts with each selected author's per-period series
+    --table                Print the authors × periods table instead of
+                           starting the UI
   --help, -h    Show this help message
   --version     Show version
 
+Commands:
+  report --html <file>  Write a self-contained HTML contributor report
+                        (ranked authors, activity sparklines, commit
+                        timelines, path ownership); use - for stdout
+  risk                  List directories by how few authors know them
+                        (top author's share, authors covering 50%/80% of
+                        changes, inactive authors); Enter shows a
+                        directory's authors
+  A repository directory named like a command is given as ./report or
+  ./risk instead.
+
 All other options are passed directly to git shortlog/log.
 See 'git shortlog --help' for available options.
 
@@ -128,33 +270,39 @@ Examples:
   gh shortlog --since="1 month ago"     # Recent commits
   gh shortlog origin..HEAD              # Commits not yet pushed
   gh shortlog -- src/                   # Only changes in src/
+  gh shortlog report --html q3.html --since=2024-07-01 --until=2024-10-01
+  gh shortlog --chart=svg --chart-output=authors.svg --chart-top=5 --chart-period=quarter
+  gh shortlog --bucket=quarter --table --since=2024-01-01
+  gh shortlog risk -- src/
+  gh shortlog --compare v1.0..v2.0 v2.0..v3.0
+  gh shortlog --by-tag --tag-pattern='v1.*'
 
 Interactive keys (press ? in the UI for full help):
   ?          Show/hide keybindings help in preview
   Tab        View commits with diffs for selected author(s)
   Enter      Filter by date (type date first, then Enter)
   Ctrl-T     Toggle multi-select for current author
-  Ctrl-W     Open author's commits in GitHub
+  Ctrl-W     Open author's commits on GitHub/GitLab/etc.
+  Ctrl-R     Choose which remote links go to (if remotes differ)
+  Alt-P      Show/hide pull requests for selected author(s) in preview
+  Alt-H      Show/hide a weekday × hour punchcard of selected author(s)' commits
+  Alt-C      Show/hide first-time, returning, continuing and lapsed authors
+             (compared with the previous period of the same length)
   Ctrl-Q     Exit and output selected items
-  Ctrl-C     Exit`)
+  Ctrl-C     Back to the previous date filter, or exit`)
 }
 
 func parseArgs(args []string) {
-	// Check for environment variables (used by subcommands)
-	if envArgs := os.Getenv("GH_SHORTLOG_ARGS"); envArgs != "" {
-		gitArgs = strings.Split(envArgs, "\x1f") // Unit separator
-	}
-	if envDir := os.Getenv("GH_SHORTLOG_DIR"); envDir != "" {
-		workDir = envDir
-	}
-	if envDate := os.Getenv("GH_SHORTLOG_DATE_FILE"); envDate != "" {
-		dateFile = envDate
-	}
-	if envBase := os.Getenv("GH_SHORTLOG_BASE_URL"); envBase != "" {
-		baseURL = envBase
-	}
-	if envOrg := os.Getenv("GH_SHORTLOG_ORG_REPO"); envOrg != "" {
-		orgAndRepo = envOrg
+	// Subcommands get the state from the parent's control server, instead
+	// of from arguments
+	if path := os.Getenv("GH_SHORTLOG_SOCKET"); path != "" && args == nil {
+		state, err := fetchState(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		applyState(state)
+		return
 	}
 
 	// Parse command line args
@@ -164,10 +312,53 @@ func parseArgs(args []string) {
 		switch {
 		case arg == "--no-mouse":
 			noMouse = true
+		case arg == "--no-stream":
+			noStream = true
+		case arg == "--deepen":
+			deepen = true
+		case arg == "--remote" && i+1 < len(args):
+			i++
+			remoteFlag = args[i]
+		case strings.HasPrefix(arg, "--remote="):
+			remoteFlag = strings.TrimPrefix(arg, "--remote=")
+		case arg == "--compare":
+			if i+2 >= len(args) {
+				fmt.Fprintln(os.Stderr, "Error: --compare needs two revision ranges, e.g. --compare v1.0..v2.0 v2.0..v3.0")
+				exit(2)
+			}
+			compareRanges = []string{args[i+1], args[i+2]}
+			i += 2
+		case strings.HasPrefix(arg, "--ui="):
+			uiName = strings.TrimPrefix(arg, "--ui=")
+		case strings.HasPrefix(arg, "--punchcard-tz="):
+			punchcardZone = strings.TrimPrefix(arg, "--punchcard-tz=")
+		case arg == "--by-tag":
+			byTag = true
+		case strings.HasPrefix(arg, "--tag-pattern="):
+			tagPattern = strings.TrimPrefix(arg, "--tag-pattern=")
+		case strings.HasPrefix(arg, "--bucket="):
+			bucketPeriod = strings.TrimPrefix(arg, "--bucket=")
+		case arg == "--table":
+			bucketTable = true
+		case strings.HasPrefix(arg, "--chart="):
+			chartFormat = strings.TrimPrefix(arg, "--chart=")
+		case strings.HasPrefix(arg, "--chart-style="):
+			chartStyle = strings.TrimPrefix(arg, "--chart-style=")
+		case strings.HasPrefix(arg, "--chart-period="):
+			chartPeriod = strings.TrimPrefix(arg, "--chart-period=")
+		case strings.HasPrefix(arg, "--chart-output="):
+			chartOutput = strings.TrimPrefix(arg, "--chart-output=")
+		case strings.HasPrefix(arg, "--chart-top="):
+			n, err := strconv.Atoi(strings.TrimPrefix(arg, "--chart-top="))
+			if err != nil || n < 1 {
+				fmt.Fprintf(os.Stderr, "Error: invalid %s (must be a positive number)\n", arg)
+				exit(2)
+			}
+			chartTop = n
 		case arg == "--":
 			// Everything after -- is a path
 			// Check if the first path after -- needs workDir resolution
-			if i+1 < len(args) && workDir == "" {
+			if i+1 < len(args) && workDir == "" && os.Getenv("GIT_DIR") == "" {
 				pathArg := args[i+1]
 				if info, err := os.Stat(pathArg); err == nil && info.IsDir() {
 					absPath, _ := filepath.Abs(pathArg)
@@ -192,31 +383,34 @@ func parseArgs(args []string) {
 			}
 			remaining = append(remaining, args[i:]...)
 			i = len(args)
+		case arg == "--git-dir" && i+1 < len(args):
+			i++
+			useGitDir(args[i])
+		case strings.HasPrefix(arg, "--git-dir="):
+			useGitDir(strings.TrimPrefix(arg, "--git-dir="))
 		default:
-			// Check if it's a directory path (first non-flag arg)
-			if workDir == "" && !strings.HasPrefix(arg, "-") {
+			// Check if it's a directory path (first non-flag arg); with
+			// GIT_DIR set, git doesn't look for the repository there
+			if workDir == "" && os.Getenv("GIT_DIR") == "" && !strings.HasPrefix(arg, "-") {
 				if info, err := os.Stat(arg); err == nil && info.IsDir() {
 					absPath, err := filepath.Abs(arg)
 					if err == nil {
-						// Check if this directory is a git repo root
-						gitDir := filepath.Join(absPath, ".git")
-						if _, err := os.Stat(gitDir); err == nil {
-							workDir = absPath
-							continue
-						}
-						// Check if it's inside a git repo (find repo root)
-						repoRoot := findGitRoot(absPath)
-						if repoRoot != "" {
+						// Find the repo it's in (or is); below the root of
+						// the work tree, it's also a path to filter by
+						if repoRoot := findGitRoot(absPath); repoRoot != "" {
 							workDir = repoRoot
-							// Convert to relative path for filtering
-							relPath, err := filepath.Rel(repoRoot, absPath)
-							if err == nil {
+							if relPath, err := filepath.Rel(repoRoot, absPath); err == nil && relPath != "." {
 								remaining = append(remaining, "--", relPath)
-								continue
 							}
+							continue
 						}
 					}
 				}
+				// Or a repository to clone (or fetch again) first
+				if remoteURL, ok := repoURL(arg); ok {
+					useRepoURL(remoteURL)
+					continue
+				}
 			}
 			remaining = append(remaining, arg)
 		}
@@ -226,66 +420,113 @@ func parseArgs(args []string) {
 }
 
 func setup() {
-	// Create temp file for date
-	if dateFile == "" {
-		tmpFile, err := os.CreateTemp("", "gh-shortlog-date-*")
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating temp file: %v\n", err)
-			os.Exit(1)
+	// Serve the state to subcommands run from fzf
+	if controlPath == "" {
+		if err := startControlServer(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error starting control server: %v\n", err)
+			exit(1)
 		}
-		dateFile = tmpFile.Name()
-		tmpFile.Close()
 	}
 
 	// Get GitHub info
 	if baseURL == "" || orgAndRepo == "" {
 		setupGitHubInfo()
 	}
+
+	setupClone()
+	logins = loadLoginCache()
+	setupFrontend()
+}
+
+// setupFrontend picks the frontend that interactive screens are shown with
+func setupFrontend() {
+	var err error
+	if ui, err = selectFrontend(uiName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(2)
+	}
 }
 
 func setupGitHubInfo() {
-	// Check for upstream first, then origin
-	remoteURL := ""
-	for _, remote := range []string{"upstream", "origin"} {
-		cmd := gitCommand("config", "--get", "remote."+remote+".url")
-		out, err := cmd.Output()
-		if err == nil && len(out) > 0 {
-			remoteURL = strings.TrimSpace(string(out))
-			break
-		}
+	// Self-hosted forges with names that don't give away what they run can
+	// be configured with: git config gh-shortlog.forge gitlab
+	configuredKind := ""
+	if out, err := gitCommand("config", "--get", "gh-shortlog.forge").Output(); err == nil {
+		configuredKind = strings.ToLower(strings.TrimSpace(string(out)))
 	}
 
-	if remoteURL == "" {
+	// Pick the remote to build links for (--remote, tracking, upstream, ...)
+	remotes = listRemotes(configuredKind)
+	remote, err := defaultRemote(remotes, remoteFlag, trackingRemote())
+	if err != nil {
+		if remoteFlag != "" {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
 		return
 	}
+	useRemote(remote)
+}
+
+// currentForge returns the forge repo that setupGitHubInfo found
+func currentForge() forgeRepo {
+	return forgeRepo{kind: forgeKind, host: forgeHost, path: orgAndRepo}
+}
 
-	// Parse GitHub URL
-	// Handles: git@github.com:org/repo.git, https://github.com/org/repo.git
-	re := regexp.MustCompile(`(?:@|//)([^/:]+)[:/]([^/]+)/([^/]+?)(?:\.git)?$`)
-	matches := re.FindStringSubmatch(remoteURL)
-	if len(matches) >= 4 {
-		host := matches[1]
-		org := matches[2]
-		repo := matches[3]
-		orgAndRepo = org + "/" + repo
-		baseURL = "https://" + host + "/" + orgAndRepo + "/commit"
+// defaultBranch returns the branch that the remote's HEAD points to, or else
+// the current branch, for author-history pages that need a ref
+func defaultBranch() string {
+	if remoteName != "" {
+		out, err := gitCommand("symbolic-ref", "--short", "refs/remotes/"+remoteName+"/HEAD").Output()
+		if err == nil {
+			return strings.TrimPrefix(strings.TrimSpace(string(out)), remoteName+"/")
+		}
 	}
+	out, err := gitCommand("rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err == nil {
+		return strings.TrimSpace(string(out))
+	}
+	return "HEAD"
 }
 
-// findGitRoot walks up from dir to find the git repository root
+// findGitRoot returns the root of the work tree that dir is in, as git
+// finds it (so worktrees and submodules, whose .git is a file, work too);
+// for a bare repository, which has no work tree, it returns dir itself. It
+// returns "" if dir isn't in a repository.
 func findGitRoot(dir string) string {
-	for {
-		gitDir := filepath.Join(dir, ".git")
-		if _, err := os.Stat(gitDir); err == nil {
-			return dir
-		}
-		parent := filepath.Dir(dir)
-		if parent == dir {
-			// Reached filesystem root
-			return ""
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--is-bare-repository", "--show-prefix").Output()
+	if err != nil {
+		return ""
+	}
+	bare, prefix, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	if bare == "true" {
+		return dir
+	}
+
+	// The root is dir without the prefix (e.g. "src/pkg/"), rather than
+	// --show-toplevel, which would resolve symlinks in the part above it
+	root := dir
+	for _, name := range strings.Split(strings.Trim(prefix, "/"), "/") {
+		if name != "" {
+			root = filepath.Dir(root)
 		}
-		dir = parent
 	}
+	return root
+}
+
+// useGitDir makes dir (with --git-dir) the repository, e.g. a bare mirror:
+// it's passed on in GIT_DIR, so that all git commands use it, as git itself
+// does for git --git-dir
+func useGitDir(dir string) {
+	abs, err := filepath.Abs(dir)
+	if err == nil {
+		err = exec.Command("git", "--git-dir="+abs, "rev-parse", "--git-dir").Run()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --git-dir: %s isn't a git repository\n", dir)
+		exit(2)
+	}
+	os.Setenv("GIT_DIR", abs)
 }
 
 func gitCommand(args ...string) *exec.Cmd {
@@ -295,47 +536,125 @@ func gitCommand(args ...string) *exec.Cmd {
 	return exec.Command("git", args...)
 }
 
+// requireCommits exits with exitNoCommits, saying so, if n (the number of
+// commits or authors found) is 0
+func requireCommits(n int) {
+	if n == 0 {
+		fmt.Fprintln(os.Stderr, "No commits match")
+		exit(exitNoCommits)
+	}
+}
+
+// gitOutput runs git and returns its output; if it fails, the error has
+// git's own message, like the shortlog package's errors
+func gitOutput(args ...string) ([]byte, error) {
+	out, err := gitCommand(args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// authorList is the author list being shown: its date filter, the earlier
+// ones (to go back to), and the counts for it
+type authorList struct {
+	dates   []string // Earlier date filters, most recent last
+	date    string   // Empty for the full history
+	input   string   // The whole list, if it wasn't streamed...
+	stream  *shortlogStream
+	badDate error // Why the date typed wasn't applied, for fzf to show when restarted
+}
+
+// load starts counting the authors for the date filter; when possible, the
+// counts are streamed, so the list shows up before the history walk ends
+func (l *authorList) load() {
+	setDateFilter(l.date)
+	l.input, l.stream = "", nil
+	if canStream() {
+		if stream, err := startShortlogStream(l.date); err == nil {
+			l.stream = stream
+		}
+	}
+	if l.stream == nil {
+		entries, err := generateShortlogEntries(l.date)
+		l.input = formatEntries(entries)
+		l.stream = newStaticStream(entries, err)
+	}
+}
+
+// push makes date the date filter, keeping the current one to go back to
+func (l *authorList) push(date string) {
+	l.dates = append(l.dates, l.date)
+	l.date = date
+}
+
+// pop goes back to the previous date filter, if there is one
+func (l *authorList) pop() bool {
+	if len(l.dates) == 0 {
+		return false
+	}
+	l.date = l.dates[len(l.dates)-1]
+	l.dates = l.dates[:len(l.dates)-1]
+	return true
+}
+
+// filter switches the list to date while it's shown, for frontends that
+// update the list in place
+func (l *authorList) filter(date string) {
+	l.stream.stop()
+	l.push(date)
+	l.load()
+}
+
+// back switches the list to the previous date filter while it's shown, if
+// there is one
+func (l *authorList) back() bool {
+	if !l.pop() {
+		return false
+	}
+	l.stream.stop()
+	l.load()
+	return true
+}
+
 func runInteractive() {
-	// State stack for back navigation
-	// Each entry is a date filter (empty string = full history)
-	var dateStack []string
-	currentDate := ""
+	list := &authorList{}
 
 	for {
-		// Generate shortlog for current date filter
-		shortlogOutput := generateShortlog(currentDate)
-
-		// Launch fzf and get result
-		action, query, selections := launchFzf(shortlogOutput, currentDate)
+		// Show the list and get result
+		list.load()
+		action, query, selections := ui.authors(list)
+		list.stream.stop()
 
 		switch action {
 		case "ctrl-o":
-			// Push current state and apply new date filter
+			// Apply new date filter (for frontends that don't do it in place)
 			if query != "" {
-				dateStack = append(dateStack, currentDate)
-				currentDate = query
+				list.push(query)
 			}
-			// Loop continues with new filter
 
 		case "back":
-			// Go back to previous state (no output on exit)
-			if len(dateStack) > 0 {
-				currentDate = dateStack[len(dateStack)-1]
-				dateStack = dateStack[:len(dateStack)-1]
-				// Loop continues with previous filter
-			} else {
-				// At root, exit silently
+			// Go back to previous state, or at root, exit silently
+			if !list.pop() {
 				return
 			}
 
+		case "dates":
+			// Pick a preset date filter
+			if date, ok := pickDate(); ok && date != list.date {
+				list.push(date)
+			}
+
+		case "remote":
+			// Switch the remote that links are built for, then redisplay
+			if remote, ok := pickRemote(remotes); ok {
+				useRemote(remote)
+				logins = loadLoginCache()
+			}
+
 		case "quit":
-			// Go back, or exit with selection output
-			if len(dateStack) > 0 {
-				currentDate = dateStack[len(dateStack)-1]
-				dateStack = dateStack[:len(dateStack)-1]
-				// Loop continues with previous filter
-			} else {
-				// At root, output selections and exit
+			// Go back, or at root, output selections and exit
+			if !list.pop() {
 				for _, sel := range selections {
 					fmt.Println(sel)
 				}
@@ -349,114 +668,109 @@ func runInteractive() {
 	}
 }
 
-func generateShortlog(sinceDate string) string {
-	args := []string{"shortlog", "-n", "-s", "-e"}
-	if sinceDate != "" {
-		args = append(args, "--since="+sinceDate)
-	}
-
-	// Check if gitArgs contains a revision (something not starting with - and not after --)
-	// and find where to insert HEAD if needed
-	hasRevision := false
-	doubleDashIndex := -1
-	for i, arg := range gitArgs {
-		if arg == "--" {
-			doubleDashIndex = i
-			break
-		}
-		if !strings.HasPrefix(arg, "-") {
-			hasRevision = true
-		}
+// generateShortlogEntries runs git shortlog and returns its parsed entries
+func generateShortlogEntries(sinceDate string) ([]shortlogEntry, error) {
+	result, err := gitQuery(sinceDate).Run()
+	if err != nil {
+		return nil, err
 	}
+	return shortlogEntries(result.Entries), nil
+}
 
-	// Build args with HEAD in correct position (before -- if present)
-	if !hasRevision {
-		if doubleDashIndex >= 0 {
-			// Insert HEAD before --
-			args = append(args, gitArgs[:doubleDashIndex]...)
-			args = append(args, "HEAD")
-			args = append(args, gitArgs[doubleDashIndex:]...)
-		} else {
-			// No --, just append everything then HEAD
-			args = append(args, gitArgs...)
-			args = append(args, "HEAD")
-		}
-	} else {
-		args = append(args, gitArgs...)
-	}
+// gitQuery is the shortlog query for gitArgs in workDir, optionally only
+// for commits after sinceDate
+func gitQuery(sinceDate string) shortlog.Query {
+	return shortlog.Query{Dir: workDir, Since: sinceDate, Args: gitArgs}
+}
 
-	cmd := gitCommand(args...)
-	out, err := cmd.Output()
-	if err != nil {
-		return ""
-	}
+// revisionArgs returns gitArgs, with HEAD added if no revision was given
+// (git shortlog reads from stdin instead of walking history otherwise)
+func revisionArgs() []string {
+	return shortlog.RevisionArgs(gitArgs)
+}
 
-	return formatShortlogOutput(string(out))
+// shortlogEntry is one author line of the shortlog
+type shortlogEntry struct {
+	count int
+	name  string
+	email string
 }
 
 func formatShortlogOutput(output string) string {
-	lines := strings.Split(strings.TrimSpace(output), "\n")
-	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
-		return ""
-	}
+	return formatEntries(parseShortlogOutput(output))
+}
 
-	type entry struct {
-		count int
-		name  string
-		email string
+// parseShortlogOutput parses git shortlog -n -s -e output into entries
+func parseShortlogOutput(output string) []shortlogEntry {
+	return shortlogEntries(shortlog.ParseShortlog(output))
+}
+
+// shortlogEntries converts the shortlog package's entries
+func shortlogEntries(entries []shortlog.Entry) []shortlogEntry {
+	var result []shortlogEntry
+	for _, e := range entries {
+		result = append(result, shortlogEntry{e.Count, e.Name, e.Email})
 	}
+	return result
+}
 
-	var entries []entry
+// formatEntries renders entries as numbered, aligned and colorized list lines,
+// followed by the author's GitHub login if it's in the login cache
+func formatEntries(entries []shortlogEntry) string {
 	maxCount := 0
 	maxName := 0
-
-	// Parse each line
-	emailRe := regexp.MustCompile(`<[^>]+>$`)
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		// Format: "  123\tName <email>"
-		parts := strings.SplitN(line, "\t", 2)
-		if len(parts) != 2 {
-			continue
+	maxEmail := 0
+	for _, e := range entries {
+		if len(e.email) > maxEmail {
+			maxEmail = len(e.email)
 		}
-
-		count, _ := strconv.Atoi(strings.TrimSpace(parts[0]))
-		rest := strings.TrimSpace(parts[1])
-
-		email := emailRe.FindString(rest)
-		name := strings.TrimSpace(strings.TrimSuffix(rest, email))
-
-		entries = append(entries, entry{count, name, email})
-
-		countLen := len(strconv.Itoa(count))
+		countLen := len(strconv.Itoa(e.count))
 		if countLen > maxCount {
 			maxCount = countLen
 		}
-		if len(name) > maxName {
-			maxName = len(name)
+		if len(e.name) > maxName {
+			maxName = len(e.name)
 		}
 	}
 
 	// Format output with line numbers and alignment
 	var result strings.Builder
 	for i, e := range entries {
-		fmt.Fprintf(&result, "%4d  %s%*d%s  %s%-*s%s  %s%s%s\n",
+		fmt.Fprintf(&result, "%4d  %s%*d%s  %s%-*s%s  %s%s%s",
 			i+1,
 			colorGreen, maxCount, e.count, colorReset,
 			colorWhite, maxName, e.name, colorReset,
 			colorCyan, e.email, colorReset)
+		// Login goes last, so fzf's {5} stays the email
+		if login, _ := logins.lookup(e.email); login != "" {
+			fmt.Fprintf(&result, "%*s  %s@%s%s", maxEmail-len(e.email), "", colorYellow, login, colorReset)
+		}
+		result.WriteString("\n")
 	}
 
 	return result.String()
 }
 
 // launchFzf runs fzf and returns the action taken, query value, and any selections
-// action is one of: "ctrl-o", "back", "quit", "accept"
-func launchFzf(input string, currentDate string) (action string, query string, selections []string) {
+// action is one of: "ctrl-o", "back", "quit", "dates", "remote", "accept"
+// If the list's stream is live, the list is fed in from it; date filters
+// typed while fzf runs are applied in place (see fzfFilterActions). fzf
+// versions that can't do that (see fzfLive) get the full list on stdin, and
+// return "ctrl-o" and "back" for date filters instead.
+func launchFzf(list *authorList) (action string, query string, selections []string) {
+	input, currentDate, stream := list.input, list.date, list.stream
+	live := fzfLive()
+
+	// ^R (remote picker) is only captured if there's a choice to make; Esc
+	// and ^C go back in place (see below), if fzf can do that
+	expectKeys := "ctrl-q,alt-d"
+	if !live {
+		expectKeys += ",ctrl-o,ctrl-c,esc,enter"
+	}
+	if hasRemoteChoice(remotes) {
+		expectKeys += ",ctrl-r"
+	}
+
 	// Build fzf arguments
 	fzfArgs := []string{
 		"--ansi",
@@ -469,7 +783,7 @@ func launchFzf(input string, currentDate string) (action string, query string, s
 		"--preview-window=border-line",
 		"--multi",
 		"--print-query",
-		"--expect", "ctrl-o,ctrl-c,ctrl-q,esc,enter", // Capture these keys
+		"--expect", expectKeys, // Capture these keys
 		"--color", "fg:15,bg:-1,hl:1",
 		"--color", "header:green:italic",
 		"--color", "prompt:80,info:40",
@@ -480,47 +794,43 @@ func launchFzf(input string, currentDate string) (action string, query string, s
 		fzfArgs = append(fzfArgs, "--no-mouse")
 	}
 
-	// Build header (just shows date filter status)
-	var header string
-	if currentDate != "" {
-		header = colorYellow + "Showing commits since " + colorWhite + currentDate + colorReset
-	} else {
-		header = colorYellow + "Showing full history" + colorReset
-	}
-	fzfArgs = append(fzfArgs, "--header", header)
+	header := listHeader(currentDate)
 
 	// Prompt with help hint - the help hint appears after the info (counts)
 	fzfArgs = append(fzfArgs, "--prompt", "Filter by name/email or date > ")
 	fzfArgs = append(fzfArgs, "--info", "inline: │ ? for help │ ")
 
-	// Build environment for subcommands
-	env := os.Environ()
-	env = append(env, "GH_SHORTLOG_ARGS="+strings.Join(gitArgs, "\x1f"))
-	env = append(env, "GH_SHORTLOG_DIR="+workDir)
-	env = append(env, "GH_SHORTLOG_DATE_FILE="+dateFile)
-	env = append(env, "GH_SHORTLOG_BASE_URL="+baseURL)
-	env = append(env, "GH_SHORTLOG_ORG_REPO="+orgAndRepo)
-
-	// Write current date to file for preview/diffs subcommands
-	os.WriteFile(dateFile, []byte(currentDate), 0644)
-
-	// Help toggle state file
-	helpStateFile, _ := os.CreateTemp("", "gh-shortlog-help-*")
-	helpStateFile.Close()
-	helpStatePath := helpStateFile.Name()
-	env = append(env, "GH_SHORTLOG_HELP_STATE="+helpStatePath)
-
-	// Preview command checks help state file to decide what to show
-	previewCmd := fmt.Sprintf("if [ -s $GH_SHORTLOG_HELP_STATE ]; then %s _help; else printf '\\n\\n'; %s _preview {+5}; fi",
-		shellQuote(selfPath), shellQuote(selfPath))
-	fzfArgs = append(fzfArgs, "--preview", previewCmd)
+	// The preview starts out showing commits; _preview asks the control
+	// server which mode it's in, to decide what to show
+	resetPreviewMode()
+	fzfArgs = append(fzfArgs, "--preview", shellQuote(selfPath)+" _preview {+5}")
 
 	// Key bindings
 	fzfArgs = append(fzfArgs, "--bind", "ctrl-b:preview-page-up,ctrl-f:preview-page-down")
 
-	// ? toggles help state and refreshes preview
-	toggleHelpCmd := fmt.Sprintf("if [ -s %s ]; then : > %s; else echo 1 > %s; fi", helpStatePath, helpStatePath, helpStatePath)
-	fzfArgs = append(fzfArgs, "--bind", fmt.Sprintf("?:execute-silent(%s)+refresh-preview", toggleHelpCmd))
+	// ? toggles help, Alt-P pull requests, Alt-H the punchcard and Alt-C the
+	// comparison with the previous period, then refresh the preview
+	for i, key := range []string{"?", "alt-p", "alt-h", "alt-c"} {
+		fzfArgs = append(fzfArgs, "--bind", fmt.Sprintf("%s:execute-silent(%s _mode %s)+refresh-preview", key, shellQuote(selfPath), previewModes[i]))
+	}
+
+	// Enter (or ^O) filters by the date typed, and Esc (or ^C) goes back to
+	// the previous date filter, without leaving fzf: _filter gets the
+	// actions that reload the list from the control server, which runs
+	// fzfFilterActions
+	var listenAddr string
+	if live {
+		for _, key := range []string{"enter", "ctrl-o"} {
+			fzfArgs = append(fzfArgs, "--bind", fmt.Sprintf("%s:transform(%s _filter {q} {5} {+5})", key, shellQuote(selfPath)))
+		}
+		for _, key := range []string{"esc", "ctrl-c"} {
+			fzfArgs = append(fzfArgs, "--bind", fmt.Sprintf("%s:transform(%s _filter --back {5} {+5})", key, shellQuote(selfPath)))
+		}
+		setLiveFilter(func(req filterRequest) string {
+			return fzfFilterActions(list, req, listenAddr)
+		})
+		defer setLiveFilter(nil)
+	}
 
 	// Tab shows diffs for selected/current author(s)
 	fzfArgs = append(fzfArgs, "--bind", fmt.Sprintf("tab:execute(clear; %s _diffs {+5}; printf \"\\nPress any key to go back...\"; read -n 1 -r)", shellQuote(selfPath)))
@@ -531,13 +841,65 @@ func launchFzf(input string, currentDate string) (action string, query string, s
 	// Ctrl-W opens browser (doesn't exit fzf)
 	fzfArgs = append(fzfArgs, "--bind", fmt.Sprintf("ctrl-w:execute(%s _browser {5})", shellQuote(selfPath)))
 
+	// A live stream starts fzf with an empty list, which is then filled
+	// in via --listen; a static one only gets login updates that way. Older
+	// fzf versions get the full list on stdin.
+	onStdin := false
+	initialHeader := header
+	if stream != nil {
+		onStdin = !stream.live()
+		if onStdin {
+			initialHeader = statusHeader(header, stream)
+		}
+		addr, err := "", errors.New("fzf can't update the list")
+		if live {
+			addr, err = freeLocalAddr()
+		}
+		if err != nil {
+			stream.stop()
+			stream = nil
+			if !onStdin {
+				entries, err := generateShortlogEntries(currentDate)
+				input = formatEntries(entries)
+				initialHeader = statusHeader(header, newStaticStream(entries, err))
+			}
+		} else {
+			listenAddr = addr
+			if !onStdin {
+				input = ""
+			}
+			fzfArgs = append(fzfArgs, "--listen="+listenAddr)
+		}
+	}
+
+	if list.badDate != nil {
+		initialHeader = errorHeader(initialHeader, list.badDate)
+		list.badDate = nil
+	}
+	fzfArgs = append(fzfArgs, "--header", initialHeader)
+
 	cmd := exec.Command("fzf", fzfArgs...)
 	cmd.Stdin = strings.NewReader(input)
 	cmd.Stderr = os.Stderr
-	cmd.Env = env
+	cmd.Env = subcommandEnv()
 
 	// Capture stdout to parse the result
-	output, _ := cmd.Output()
+	var stdout strings.Builder
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err == nil {
+		if stream != nil {
+			sent := -1
+			if onStdin {
+				_, _, sent, _ = stream.snapshot()
+			}
+			go stream.feed(listenAddr, header, sent, nil)
+		}
+		cmd.Wait()
+	}
+	if stream != nil {
+		stream.stop()
+	}
+	output := stdout.String()
 
 	// Parse output: first line is query, second line is key pressed, rest is selection
 	// Note: fzf may exit with status 1 when using --expect without selection, but output is still valid
@@ -559,21 +921,143 @@ func launchFzf(input string, currentDate string) (action string, query string, s
 	}
 
 	switch key {
-	case "ctrl-o":
-		// ^O = date filter shortcut (always uses query as date)
-		return "ctrl-o", query, selections
-	case "enter":
-		// Enter always applies date filter (empty query = full history)
+	case "ctrl-o", "enter": // Only expected without live filtering
+		if query != "" {
+			if err := checkDate(query); err != nil {
+				// Show the list again, saying why
+				list.badDate = err
+				return "ctrl-o", "", selections
+			}
+		}
 		return "ctrl-o", query, selections
 	case "ctrl-c", "esc":
 		return "back", query, selections
 	case "ctrl-q":
 		return "quit", query, selections
+	case "alt-d":
+		return "dates", query, selections
+	case "ctrl-r":
+		return "remote", query, selections
 	default:
 		return "accept", query, selections
 	}
 }
 
+// fzfFilterActions switches list to the date filter in req (or with
+// req.Back, to the previous one), and returns the fzf actions that show it
+// in place of the current list: the new list, with the cursor and the
+// selection kept on the same authors (by email), and the new header. If git
+// doesn't understand the date, the list stays as it is, and the header says
+// why; going back from the first date filter leaves fzf.
+func fzfFilterActions(list *authorList, req filterRequest, listenAddr string) string {
+	if req.Back {
+		if !list.back() {
+			return "abort"
+		}
+	} else {
+		if strings.TrimSpace(req.Date) == "" {
+			return ""
+		}
+		if err := checkDate(req.Date); err != nil {
+			return "change-header:" + errorHeader(listHeader(list.date), err)
+		}
+		list.filter(req.Date)
+	}
+
+	// fzf waits for transform, so it gets what's counted so far, and the
+	// rest is streamed in like the unfiltered list (with the cursor and the
+	// selection restored once it's complete), then the logins
+	entries, _, version, done := list.stream.snapshot()
+	listPath := filepath.Join(controlDir, "filtered")
+	if err := writeFileAtomic(listPath, []byte(formatEntries(entries))); err != nil {
+		return ""
+	}
+	header := listHeader(list.date)
+	restore := func(entries []shortlogEntry) []string {
+		return restoreActions(entries, req.Current, req.Selected)
+	}
+
+	actions := []string{"clear-query", "clear-selection", "reload-sync(cat " + shellQuote(listPath) + ")"}
+	if done {
+		actions = append(actions, restore(entries)...)
+		restore = nil
+	}
+	if listenAddr != "" {
+		go list.stream.feed(listenAddr, header, version, restore)
+	}
+	actions = append(actions, "refresh-preview", "change-header:"+statusHeader(header, list.stream))
+	return strings.Join(actions, "+")
+}
+
+// restoreActions returns the fzf actions that select the authors with the
+// selected emails in entries, and put the cursor on the current one
+func restoreActions(entries []shortlogEntry, current string, selected []string) []string {
+	isSelected := make(map[string]bool)
+	for _, email := range selected {
+		isSelected[email] = true
+	}
+	var actions []string
+	cursor := "first"
+	for i, e := range entries {
+		if isSelected[e.email] {
+			actions = append(actions, fmt.Sprintf("pos(%d)", i+1), "select")
+		}
+		if e.email == current && cursor == "first" {
+			cursor = fmt.Sprintf("pos(%d)", i+1)
+		}
+	}
+	return append(actions, cursor)
+}
+
+// listHeader returns the author list's header, which shows the date filter
+// (and the remote links go to, and the bucket period, if there's a choice)
+func listHeader(currentDate string) string {
+	var header string
+	if currentDate != "" {
+		header = colorYellow + "Showing commits since " + colorWhite + currentDate + colorReset
+	} else {
+		header = colorYellow + "Showing full history" + colorReset
+	}
+	if hasRemoteChoice(remotes) && remoteName != "" {
+		header += colorYellow + "  │  Links: " + colorWhite + remoteName + colorReset
+	}
+	if bucketPeriod != "" {
+		header += colorYellow + "  │  Per " + colorWhite + bucketPeriod + colorReset
+	}
+	if note := clone.headerNote(); note != "" {
+		header += colorRed + "  │  " + note + colorReset
+	}
+	return header
+}
+
+// statusHeader returns the author list's header with the state of the
+// counts in s after it: the progress while counting, then whether git failed
+// or no commits matched
+func statusHeader(header string, s *shortlogStream) string {
+	entries, commits, _, done := s.snapshot()
+	switch {
+	case s.failed() != nil:
+		return errorHeader(header, s.failed())
+	case !done:
+		return header + colorReset + fmt.Sprintf(" (counting… %d commits so far)", commits)
+	case len(entries) == 0:
+		return header + colorYellow + "  │  No commits match" + colorReset
+	}
+	return header
+}
+
+// errorHeader returns header with (the first line of) err after it
+func errorHeader(header string, err error) string {
+	msg, _, _ := strings.Cut(err.Error(), "\n")
+	return header + colorRed + "  │  " + msg + colorReset
+}
+
+// subcommandEnv returns the environment for subcommands run from fzf,
+// which tells them where to get the state from (see parseArgs)
+func subcommandEnv() []string {
+	return append(os.Environ(), "GH_SHORTLOG_SOCKET="+controlPath)
+}
+
 func shellQuote(s string) string {
 	if strings.ContainsAny(s, " \t\n'\"\\") {
 		return "'" + strings.ReplaceAll(s, "'", "'\"'\"'") + "'"
@@ -583,18 +1067,35 @@ func shellQuote(s string) string {
 
 // Subcommand: _preview
 func runPreviewSubcommand(args []string) {
-	parseArgs(nil) // Load from env
+	parseArgs(nil) // Load the state from the control server
 
 	if len(args) < 1 {
 		return
 	}
+	writePreviewMode(os.Stdout, previewMode, args, dateFilter)
+}
 
-	// Read date from file
-	sinceDate := ""
-	if data, err := os.ReadFile(dateFile); err == nil {
-		sinceDate = strings.TrimSpace(string(data))
+// writePreviewMode writes the preview for mode (see previewModes) of the
+// authors' commits since sinceDate
+func writePreviewMode(w io.Writer, mode string, args []string, sinceDate string) {
+	switch mode {
+	case "help":
+		fmt.Fprint(w, helpText)
+	case "prs":
+		writePulls(w, args, sinceDate)
+	case "punchcard":
+		writePunchcard(w, args, sinceDate)
+	case "churn":
+		writeChurn(w, sinceDate)
+	default:
+		fmt.Fprint(w, "\n\n")
+		writePreview(w, args, sinceDate)
 	}
+}
 
+// writePreview writes the authors' commits since sinceDate, with links and
+// pull requests, as shown in the preview
+func writePreview(w io.Writer, args []string, sinceDate string) {
 	// Build git log command
 	// Support multiple authors (from fzf {+5} multi-select)
 	logArgs := []string{"log", "--no-patch", "--format=fuller", "--notes", "--color"}
@@ -606,37 +1107,85 @@ func runPreviewSubcommand(args []string) {
 	}
 	logArgs = append(logArgs, gitArgs...)
 
-	cmd := gitCommand(logArgs...)
-	out, err := cmd.Output()
+	out, err := gitOutput(logArgs...)
 	if err != nil {
+		fmt.Fprintf(w, "%s%v%s\n", colorRed, err, colorReset)
+		return
+	}
+	if len(out) == 0 {
+		fmt.Fprintf(w, "%sNo commits match%s\n", colorYellow, colorReset)
 		return
 	}
 
-	// Replace commit hashes with URLs (handle ANSI codes around commit line)
+	// Annotate commits with the pull requests they were merged through
 	output := string(out)
+	if shas, oldest := previewCommits(args, sinceDate); len(shas) > 0 {
+		output = annotatePulls(output, commitPulls(shas, oldest), currentForge())
+	}
+
+	// Replace commit hashes with URLs (handle ANSI codes around commit line)
 	if baseURL != "" {
 		// Match 40-char commit hash, keep first 10 chars and replace with URL
 		re := regexp.MustCompile(`(commit )([0-9a-f]{10})([0-9a-f]{30})`)
 		output = re.ReplaceAllString(output, "${1}"+baseURL+"/${2}")
 	}
 
-	fmt.Print(output)
+	// In bucketed mode, start with the authors' commits per period
+	if bucketPeriod != "" {
+		output = bucketSeries(args, sinceDate, bucketPeriod) + output
+	}
+
+	fmt.Fprint(w, output)
+}
+
+// previewCommits returns the hashes (newest first) of the commits that the
+// preview shows, and the commit time of the oldest of them
+func previewCommits(authors []string, sinceDate string) (shas []string, oldest time.Time) {
+	logArgs := []string{"log", "--format=%H %ct"}
+	for _, author := range authors {
+		logArgs = append(logArgs, "--author="+author)
+	}
+	if sinceDate != "" {
+		logArgs = append(logArgs, "--since="+sinceDate)
+	}
+	logArgs = append(logArgs, gitArgs...)
+
+	out, err := gitCommand(logArgs...).Output()
+	if err != nil {
+		return nil, oldest
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		sha, ts, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		shas = append(shas, sha)
+		if secs, err := strconv.ParseInt(ts, 10, 64); err == nil {
+			if t := time.Unix(secs, 0); oldest.IsZero() || t.Before(oldest) {
+				oldest = t
+			}
+		}
+	}
+	return shas, oldest
 }
 
 // Subcommand: _diffs
 func runDiffsSubcommand(args []string) {
-	parseArgs(nil) // Load from env
+	parseArgs(nil) // Load the state from the control server
 
 	if len(args) < 1 {
 		return
 	}
 
-	// Read date from file
-	sinceDate := ""
-	if data, err := os.ReadFile(dateFile); err == nil {
-		sinceDate = strings.TrimSpace(string(data))
-	}
+	cmd := diffsCommand(args, dateFilter)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Run()
+}
 
+// diffsCommand returns the git log command showing the authors' commits
+// since sinceDate with their diffs
+func diffsCommand(args []string, sinceDate string) *exec.Cmd {
 	// Build git log command with diffs
 	// Support multiple authors (from fzf {+5} multi-select)
 	logArgs := []string{"log", "-w", "--patch-with-stat", "--format=fuller", "--notes", "--color"}
@@ -648,62 +1197,77 @@ func runDiffsSubcommand(args []string) {
 	}
 	logArgs = append(logArgs, gitArgs...)
 
-	cmd := gitCommand(logArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	cmd.Run()
+	return gitCommand(logArgs...)
 }
 
 // Subcommand: _browser
 func runBrowserSubcommand(args []string) {
-	parseArgs(nil) // Load from env
+	parseArgs(nil) // Load the state from the control server
 
-	if len(args) < 1 || orgAndRepo == "" {
+	if len(args) < 1 {
 		return
 	}
-
-	email := args[0]
-	// Extract author from email: remove <>, handle noreply format
-	author := strings.Trim(email, "<>")
-	// Handle GitHub noreply: 12345+username@users.noreply.github.com
-	re := regexp.MustCompile(`^[^+]+\+([^@]+)@.*$`)
-	if matches := re.FindStringSubmatch(author); len(matches) > 1 {
-		author = matches[1]
+	logins = loadLoginCache()
+	if err := openAuthorPage(args[0], dateFilter); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
 	}
+}
 
-	// Get GitHub login via API
-	login := getGitHubLogin(author)
-	if login == "" {
-		login = author
+// openAuthorPage opens the forge's list of the author's commits (since
+// sinceDate, on GitHub) in the browser. If the author's GitHub login can't
+// be looked up, the page is for their email instead, and the error says why.
+func openAuthorPage(email, sinceDate string) error {
+	if orgAndRepo == "" {
+		return nil
 	}
+	forge := currentForge()
+	var err error
 
-	// Read date from file
-	sinceDate := ""
-	if data, err := os.ReadFile(dateFile); err == nil {
-		sinceDate = strings.TrimSpace(string(data))
-	}
+	// Other forges filter commit lists by author name or email, so only
+	// GitHub needs a login
+	author := strings.Trim(email, "<>")
+	if forge.kind == forgeGitHub {
+		// Handle GitHub noreply: 12345+username@users.noreply.github.com
+		re := regexp.MustCompile(`^[^+]+\+([^@]+)@.*$`)
+		if matches := re.FindStringSubmatch(author); len(matches) > 1 {
+			author = matches[1]
+		}
 
-	// Build URL
-	url := fmt.Sprintf("https://github.com/%s/commits?author=%s", orgAndRepo, login)
-	if sinceDate != "" {
-		// Format date for GitHub
-		formattedDate := formatDateForGitHub(sinceDate)
-		if formattedDate != "" {
-			url += "&since=" + formattedDate
+		// Use a previously resolved login if there is one (works offline);
+		// otherwise get the GitHub login via API, and remember it
+		login, cached := logins.lookup(email)
+		if !cached {
+			login, err = getGitHubLogin(author)
+			if login != "" {
+				logins.store(map[string]string{email: login})
+			}
+		}
+		if login != "" {
+			author = login
 		}
 	}
 
-	openBrowser(url)
+	// Format date for GitHub (the only forge that filters by date)
+	formattedDate := ""
+	if sinceDate != "" && forge.kind == forgeGitHub {
+		formattedDate = formatDateForGitHub(sinceDate)
+	}
+
+	openBrowser(forge.authorURL(defaultBranch(), author, formattedDate))
+	return err
 }
 
-func getGitHubLogin(author string) string {
-	// Use gh CLI to get login
-	cmd := exec.Command("gh", "api", fmt.Sprintf("/repos/%s/commits?author=%s&per_page=1", orgAndRepo, author), "--jq", ".[] | .author.login")
+// getGitHubLogin looks up the GitHub login of the author of the latest
+// commit by author (an email), with the gh CLI
+func getGitHubLogin(author string) (string, error) {
+	cmd := ghAPICommand(fmt.Sprintf("/repos/%s/commits?author=%s&per_page=1", orgAndRepo, url.QueryEscape(author)), "--jq", ".[] | .author.login")
 	out, err := cmd.Output()
-	if err != nil {
-		return ""
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return "", fmt.Errorf("looking up the GitHub login for %s: %s", author, strings.TrimSpace(string(exitErr.Stderr)))
+	} else if err != nil {
+		return "", fmt.Errorf("looking up the GitHub login for %s: %v", author, err)
 	}
-	return strings.TrimSpace(string(out))
+	return strings.TrimSpace(string(out)), nil
 }
 
 func formatDateForGitHub(date string) string {
@@ -737,4 +1301,3 @@ func openBrowser(url string) {
 	}
 	cmd.Run()
 }
-
@@ -1,13 +1,44 @@
 package main
 
 import (
+	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
-	"regexp"
+	"reflect"
 	"strings"
 	"testing"
 )
 
+// testRepo makes an empty repository (on branch main) for tests that need
+// git, skipping them without it, and returns it with a function that runs
+// git in it and returns the output. Commits are by Jane Smith, unless
+// --author says otherwise.
+func testRepo(t *testing.T) (string, func(args ...string) string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	git := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Jane Smith", "GIT_AUTHOR_EMAIL=jane@example.com",
+			"GIT_COMMITTER_NAME=Jane Smith", "GIT_COMMITTER_EMAIL=jane@example.com")
+		out, err := cmd.Output()
+		if err != nil {
+			stderr := ""
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				stderr = string(exitErr.Stderr)
+			}
+			t.Fatalf("git %v: %v\n%s", args, err, stderr)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-q", "-b", "main")
+	return dir, git
+}
+
 func TestShellQuote(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -123,24 +154,21 @@ func TestFormatShortlogOutputAlignment(t *testing.T) {
 }
 
 func TestFindGitRoot(t *testing.T) {
-	// Create a temporary directory structure
-	tmpDir, err := os.MkdirTemp("", "gh-shortlog-test-*")
-	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	// Create structure: tmpDir/repo/.git and tmpDir/repo/src/pkg
-	repoDir := filepath.Join(tmpDir, "repo")
-	gitDir := filepath.Join(repoDir, ".git")
+	// A repo with src/pkg in it, a bare one, and a worktree (.git is a
+	// file there)
+	repoDir, git := testRepo(t)
 	srcDir := filepath.Join(repoDir, "src")
 	pkgDir := filepath.Join(srcDir, "pkg")
-
-	for _, dir := range []string{gitDir, pkgDir} {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			t.Fatalf("failed to create dir %s: %v", dir, err)
-		}
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("failed to create dir %s: %v", pkgDir, err)
 	}
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+
+	tmpDir := t.TempDir()
+	bareDir := filepath.Join(tmpDir, "bare.git")
+	worktreeDir := filepath.Join(tmpDir, "worktree")
+	git("init", "-q", "--bare", bareDir)
+	git("worktree", "add", "-q", worktreeDir)
 
 	tests := []struct {
 		name     string
@@ -167,6 +195,16 @@ func TestFindGitRoot(t *testing.T) {
 			startDir: tmpDir,
 			expected: "",
 		},
+		{
+			name:     "bare repo",
+			startDir: bareDir,
+			expected: bareDir,
+		},
+		{
+			name:     "worktree",
+			startDir: worktreeDir,
+			expected: worktreeDir,
+		},
 	}
 
 	for _, tt := range tests {
@@ -180,8 +218,7 @@ func TestFindGitRoot(t *testing.T) {
 }
 
 func TestParseGitHubURL(t *testing.T) {
-	// Test the regex used in setupGitHubInfo
-	re := regexp.MustCompile(`(?:@|//)([^/:]+)[:/]([^/]+)/([^/]+?)(?:\.git)?$`)
+	// Test the remote URL parsing used in setupGitHubInfo
 
 	tests := []struct {
 		name        string
@@ -240,23 +277,23 @@ func TestParseGitHubURL(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			matches := re.FindStringSubmatch(tt.url)
+			repo, ok := parseRemoteURL(tt.url, "")
 			if tt.shouldMatch {
-				if len(matches) < 4 {
+				if !ok {
 					t.Fatalf("expected match for %q, got none", tt.url)
 				}
-				if matches[1] != tt.wantHost {
-					t.Errorf("host = %q, want %q", matches[1], tt.wantHost)
+				if repo.host != tt.wantHost {
+					t.Errorf("host = %q, want %q", repo.host, tt.wantHost)
 				}
-				if matches[2] != tt.wantOrg {
-					t.Errorf("org = %q, want %q", matches[2], tt.wantOrg)
+				if repo.path != tt.wantOrg+"/"+tt.wantRepo {
+					t.Errorf("path = %q, want %q", repo.path, tt.wantOrg+"/"+tt.wantRepo)
 				}
-				if matches[3] != tt.wantRepo {
-					t.Errorf("repo = %q, want %q", matches[3], tt.wantRepo)
+				if repo.kind != forgeGitHub {
+					t.Errorf("kind = %q, want %q", repo.kind, forgeGitHub)
 				}
 			} else {
-				if len(matches) > 0 {
-					t.Errorf("expected no match for %q, got %v", tt.url, matches)
+				if ok {
+					t.Errorf("expected no match for %q, got %+v", tt.url, repo)
 				}
 			}
 		})
@@ -289,6 +326,35 @@ func TestParseArgsNoMouse(t *testing.T) {
 	}
 }
 
+func TestParseArgsRemote(t *testing.T) {
+	oldGitArgs := gitArgs
+	oldWorkDir := workDir
+	oldRemoteFlag := remoteFlag
+	defer func() {
+		gitArgs = oldGitArgs
+		workDir = oldWorkDir
+		remoteFlag = oldRemoteFlag
+	}()
+
+	for _, args := range [][]string{
+		{"--remote", "gh", "--since=1 month ago"},
+		{"--remote=gh", "--since=1 month ago"},
+	} {
+		gitArgs = nil
+		workDir = ""
+		remoteFlag = ""
+
+		parseArgs(args)
+
+		if remoteFlag != "gh" {
+			t.Errorf("parseArgs(%q): remoteFlag = %q, want %q", args, remoteFlag, "gh")
+		}
+		if len(gitArgs) != 1 || gitArgs[0] != "--since=1 month ago" {
+			t.Errorf("parseArgs(%q): gitArgs = %v, want [--since=1 month ago]", args, gitArgs)
+		}
+	}
+}
+
 func TestParseArgsPassThrough(t *testing.T) {
 	// Save and restore global state
 	oldGitArgs := gitArgs
@@ -320,6 +386,11 @@ func TestParseArgsPassThrough(t *testing.T) {
 			args:     []string{"-n", "10", "--since=1 week ago"},
 			wantArgs: []string{"-n", "10", "--since=1 week ago"},
 		},
+		{
+			name:     "git log options like the chart's",
+			args:     []string{"--output=log.txt", "--top=3", "--period=month"},
+			wantArgs: []string{"--output=log.txt", "--top=3", "--period=month"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -344,23 +415,12 @@ func TestParseArgsPassThrough(t *testing.T) {
 }
 
 func TestParseArgsDirectoryHandling(t *testing.T) {
-	// Create a temporary git repo structure
-	tmpDir, err := os.MkdirTemp("", "gh-shortlog-test-*")
-	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	// Create: tmpDir/repo/.git and tmpDir/repo/src/pkg
-	repoDir := filepath.Join(tmpDir, "repo")
-	gitDir := filepath.Join(repoDir, ".git")
+	// A repo with src/pkg in it
+	repoDir, _ := testRepo(t)
 	srcDir := filepath.Join(repoDir, "src")
 	pkgDir := filepath.Join(srcDir, "pkg")
-
-	for _, dir := range []string{gitDir, pkgDir} {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			t.Fatalf("failed to create dir %s: %v", dir, err)
-		}
+	if err := os.MkdirAll(pkgDir, 0755); err != nil {
+		t.Fatalf("failed to create dir %s: %v", pkgDir, err)
 	}
 
 	// Save and restore global state
@@ -430,21 +490,10 @@ func TestParseArgsDirectoryHandling(t *testing.T) {
 }
 
 func TestParseArgsDoubleDash(t *testing.T) {
-	// Create a temporary git repo structure
-	tmpDir, err := os.MkdirTemp("", "gh-shortlog-test-*")
-	if err != nil {
-		t.Fatalf("failed to create temp dir: %v", err)
-	}
-	defer os.RemoveAll(tmpDir)
-
-	repoDir := filepath.Join(tmpDir, "repo")
-	gitDir := filepath.Join(repoDir, ".git")
+	repoDir, _ := testRepo(t)
 	srcDir := filepath.Join(repoDir, "src")
-
-	for _, dir := range []string{gitDir, srcDir} {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			t.Fatalf("failed to create dir %s: %v", dir, err)
-		}
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("failed to create dir %s: %v", srcDir, err)
 	}
 
 	// Save and restore global state
@@ -508,8 +557,12 @@ func TestHelpTextKeyBindings(t *testing.T) {
 		"Enter",  // Date filter
 		"^T",     // Toggle multi-select
 		"^W",     // Open browser
+		"^R",     // Choose remote
+		"Alt-P",  // Pull requests
+		"Alt-H",  // Punchcard
+		"Alt-C",  // Churn comparison
 		"^Q",     // Exit with output
-		"^C/Esc", // Exit
+		"^C/Esc", // Back, or exit
 		"^F/^B",  // Scroll preview
 	}
 
@@ -604,3 +657,158 @@ func TestGenerateShortlogHeadInsertion(t *testing.T) {
 		})
 	}
 }
+
+func TestRevisionArgs(t *testing.T) {
+	oldGitArgs := gitArgs
+	defer func() { gitArgs = oldGitArgs }()
+
+	tests := []struct {
+		name     string
+		gitArgs  []string
+		wantArgs []string
+	}{
+		{"empty args", nil, []string{"HEAD"}},
+		{"only flags", []string{"--since=1 week ago"}, []string{"--since=1 week ago", "HEAD"}},
+		{"with revision", []string{"HEAD~10..HEAD"}, []string{"HEAD~10..HEAD"}},
+		{"path after double dash", []string{"--", "src/"}, []string{"HEAD", "--", "src/"}},
+		{"revision before double dash", []string{"origin..HEAD", "--", "src/"}, []string{"origin..HEAD", "--", "src/"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitArgs = tt.gitArgs
+			got := revisionArgs()
+			if strings.Join(got, "\x00") != strings.Join(tt.wantArgs, "\x00") {
+				t.Errorf("revisionArgs() = %q, want %q", got, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestAuthorListDates(t *testing.T) {
+	var list authorList
+	if list.pop() {
+		t.Error("pop at the full history should fail")
+	}
+	list.push("1 year ago")
+	list.push("1 month ago")
+	if list.date != "1 month ago" {
+		t.Errorf("date = %q, want 1 month ago", list.date)
+	}
+	for _, want := range []string{"1 year ago", ""} {
+		if !list.pop() || list.date != want {
+			t.Errorf("after pop: date = %q, want %q", list.date, want)
+		}
+	}
+	if list.pop() {
+		t.Error("pop past the full history should fail")
+	}
+}
+
+func TestFzfFilterActions(t *testing.T) {
+	dir, git := testRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	oldWorkDir, oldGitArgs, oldControlDir := workDir, gitArgs, controlDir
+	defer func() { workDir, gitArgs, controlDir = oldWorkDir, oldGitArgs, oldControlDir }()
+	workDir, gitArgs, controlDir = dir, nil, t.TempDir()
+
+	list := &authorList{}
+	list.load()
+	defer func() { list.stream.stop() }()
+
+	current := "<jane@example.com>"
+	if got := fzfFilterActions(list, filterRequest{Date: "not a date", Current: current}, ""); !strings.HasPrefix(got, "change-header:") || list.date != "" {
+		t.Errorf("invalid date: %q, date = %q", got, list.date)
+	}
+	// The filtered list is reloaded at once, without waiting for git log
+	got := fzfFilterActions(list, filterRequest{Date: "1 year ago", Current: current}, "")
+	if !strings.Contains(got, "reload-sync(") || list.date != "1 year ago" {
+		t.Errorf("filter: %q, date = %q", got, list.date)
+	}
+
+	// Back goes to the previous date filter in place, and from the first
+	// one, leaves fzf
+	got = fzfFilterActions(list, filterRequest{Back: true, Current: current}, "")
+	if !strings.Contains(got, "reload-sync(") || list.date != "" {
+		t.Errorf("back: %q, date = %q", got, list.date)
+	}
+	if got := fzfFilterActions(list, filterRequest{Back: true, Current: current}, ""); got != "abort" {
+		t.Errorf("back from the first date filter: %q, want abort", got)
+	}
+}
+
+func TestRestoreActions(t *testing.T) {
+	entries := []shortlogEntry{
+		{10, "Jane Smith", "<jane@example.com>"},
+		{5, "John Doe", "<john@example.com>"},
+		{2, "Mary Major", "<mary@example.com>"},
+	}
+
+	tests := []struct {
+		name     string
+		current  string
+		selected []string
+		want     []string
+	}{
+		{"no selection", "<john@example.com>", nil, []string{"pos(2)"}},
+		{"selection", "<jane@example.com>", []string{"<mary@example.com>", "<jane@example.com>"},
+			[]string{"pos(1)", "select", "pos(3)", "select", "pos(1)"}},
+		{"authors gone from the list", "<gone@example.com>", []string{"<gone@example.com>"}, []string{"first"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := restoreActions(entries, tt.current, tt.selected); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restoreActions() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatusHeader(t *testing.T) {
+	entries := []shortlogEntry{{3, "Jane Smith", "<jane@example.com>"}}
+	counting := &shortlogStream{counts: map[string]*shortlogEntry{"jane": &entries[0]}, commits: 3, quit: make(chan struct{})}
+
+	tests := []struct {
+		name   string
+		stream *shortlogStream
+		want   string // Text after the header, without colors
+	}{
+		{"complete", newStaticStream(entries, nil), ""},
+		{"counting", counting, " (counting… 3 commits so far)"},
+		{"no commits", newStaticStream(nil, nil), "  │  No commits match"},
+		{"failed", newStaticStream(nil, errors.New("git shortlog: fatal: bad revision 'x'\nmore")), "  │  git shortlog: fatal: bad revision 'x'"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.stream.stop()
+			if got := stripANSI(statusHeader("HEADER", tt.stream)); got != "HEADER"+tt.want {
+				t.Errorf("statusHeader = %q, want %q", got, "HEADER"+tt.want)
+			}
+		})
+	}
+}
+
+func TestParseArgsGitDir(t *testing.T) {
+	dir, git := testRepo(t)
+	bareDir := filepath.Join(dir, "mirror.git")
+	git("init", "-q", "--bare", bareDir)
+
+	oldGitArgs, oldWorkDir := gitArgs, workDir
+	defer func() { gitArgs, workDir = oldGitArgs, oldWorkDir }()
+	t.Setenv("GIT_DIR", "") // Restored after the test
+
+	for _, args := range [][]string{
+		{"--git-dir=" + bareDir, "--since=1 week ago"},
+		{"--git-dir", bareDir, "--since=1 week ago"},
+	} {
+		gitArgs, workDir = nil, ""
+		parseArgs(args)
+		if os.Getenv("GIT_DIR") != bareDir || workDir != "" || !reflect.DeepEqual(gitArgs, []string{"--since=1 week ago"}) {
+			t.Errorf("parseArgs(%q): GIT_DIR = %q, workDir = %q, gitArgs = %q", args, os.Getenv("GIT_DIR"), workDir, gitArgs)
+		}
+		out, err := gitCommand("rev-parse", "--is-bare-repository").Output()
+		if err != nil || strings.TrimSpace(string(out)) != "true" {
+			t.Errorf("git doesn't use the --git-dir repository: %q, %v", out, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Time periods that commits can be counted per
+var periodNames = []string{"week", "month", "quarter", "year"}
+
+// validPeriod reports whether period is one of periodNames
+func validPeriod(period string) bool {
+	for _, name := range periodNames {
+		if period == name {
+			return true
+		}
+	}
+	return false
+}
+
+// periodStart returns the start of the period that t is in (in UTC;
+// weeks start on Monday)
+func periodStart(t time.Time, period string) time.Time {
+	t = t.UTC()
+	switch period {
+	case "week":
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "quarter":
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case "year":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextPeriod returns the start of the period after the one starting at start
+func nextPeriod(start time.Time, period string) time.Time {
+	switch period {
+	case "week":
+		return start.AddDate(0, 0, 7)
+	case "quarter":
+		return start.AddDate(0, 3, 0)
+	case "year":
+		return start.AddDate(1, 0, 0)
+	default:
+		return start.AddDate(0, 1, 0)
+	}
+}
+
+// periodLabel names the period starting at start, e.g. "2024-03", "2024-Q1"
+func periodLabel(start time.Time, period string) string {
+	switch period {
+	case "week":
+		return start.Format("2006-01-02")
+	case "quarter":
+		return fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())-1)/3+1)
+	case "year":
+		return start.Format("2006")
+	default:
+		return start.Format("2006-01")
+	}
+}
+
+// periodsBetween returns the starts of all periods from the one containing
+// oldest through the one containing newest
+func periodsBetween(oldest, newest time.Time, period string) []time.Time {
+	var starts []time.Time
+	last := periodStart(newest, period)
+	for p := periodStart(oldest, period); !p.After(last); p = nextPeriod(p, period) {
+		starts = append(starts, p)
+	}
+	return starts
+}
+
+// periodCounts counts commits per author key and per period, for the
+// periods returned by periodsBetween for the commits' time span
+func periodCounts(commits []historyCommit, period string) (starts []time.Time, counts map[string][]int) {
+	counts = make(map[string][]int)
+	if len(commits) == 0 {
+		return nil, counts
+	}
+
+	oldest, newest := commits[0].when, commits[0].when
+	for _, c := range commits {
+		if c.when.Before(oldest) {
+			oldest = c.when
+		}
+		if c.when.After(newest) {
+			newest = c.when
+		}
+	}
+	starts = periodsBetween(oldest, newest, period)
+
+	index := make(map[time.Time]int)
+	for i, start := range starts {
+		index[start] = i
+	}
+	for _, c := range commits {
+		key := authorKey(c)
+		if counts[key] == nil {
+			counts[key] = make([]int, len(starts))
+		}
+		counts[key][index[periodStart(c.when, period)]]++
+	}
+	return starts, counts
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPeriodStartAndLabel(t *testing.T) {
+	// A Thursday
+	when := time.Date(2024, 5, 16, 22, 30, 0, 0, time.FixedZone("", -5*3600))
+	tests := []struct {
+		period string
+		start  time.Time
+		label  string
+	}{
+		{"week", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC), "2024-05-13"},
+		{"month", time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), "2024-05"},
+		{"quarter", time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), "2024-Q2"},
+		{"year", time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "2024"},
+	}
+	for _, tt := range tests {
+		start := periodStart(when, tt.period)
+		if !start.Equal(tt.start) {
+			t.Errorf("periodStart(%s) = %v, want %v", tt.period, start, tt.start)
+		}
+		if label := periodLabel(start, tt.period); label != tt.label {
+			t.Errorf("periodLabel(%s) = %q, want %q", tt.period, label, tt.label)
+		}
+	}
+}
+
+func TestPeriodCounts(t *testing.T) {
+	commits := []historyCommit{
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 7, 2, 0, 0, 0, 0, time.UTC)},
+		{name: "John", email: "<john@example.com>", when: time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)},
+		{name: "Jane", email: "<jane@example.com>", when: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)},
+	}
+
+	starts, counts := periodCounts(commits, "quarter")
+	if len(starts) != 3 {
+		t.Fatalf("expected 3 quarters, got %v", starts)
+	}
+	if want := []int{2, 0, 1}; !reflect.DeepEqual(counts["Jane <jane@example.com>"], want) {
+		t.Errorf("Jane's counts = %v, want %v", counts["Jane <jane@example.com>"], want)
+	}
+	if want := []int{1, 0, 0}; !reflect.DeepEqual(counts["John <john@example.com>"], want) {
+		t.Errorf("John's counts = %v, want %v", counts["John <john@example.com>"], want)
+	}
+
+	if starts, _ := periodCounts(nil, "month"); starts != nil {
+		t.Errorf("expected no periods without commits, got %v", starts)
+	}
+}
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Help for the prompt frontend's author list
+const promptHelp = `Commands (N is an author's number; several can be given, e.g. "2 5"):
+  N...          Show the authors' commits
+  d N...        Show the authors' commits with diffs
+  w N           Open the author's commits on GitHub/GitLab/etc.
+  p N...        Show the pull requests for the authors' commits
+  h N...        Show a weekday × hour punchcard of the authors' commits
+  c             Show first-time, returning, continuing and lapsed authors
+  s N...        Select/unselect authors
+  / TEXT        Only list authors whose name or email contains TEXT (/ alone: all)
+  f DATE        Filter by date, e.g. "f 3 months ago" (f alone: choose a preset)
+  r             Choose which remote links go to
+  b             Back (or exit, at the top)
+  q             Exit (or go back), and on final exit, output the selected authors
+  ?             Show this help
+`
+
+// promptFrontend shows the screens as plain lines, and reads commands
+// from stdin; for terminals (or servers) without fzf. Everything but the
+// final output goes to stderr.
+type promptFrontend struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPromptFrontend() *promptFrontend {
+	return &promptFrontend{in: bufio.NewReader(os.Stdin), out: os.Stderr}
+}
+
+// readCommand prompts for a command and returns its name and arguments
+func (p *promptFrontend) readCommand(prompt string) (cmd, arg string, ok bool) {
+	fmt.Fprintf(p.out, "%s%s>%s ", colorYellow, prompt, colorReset)
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(p.out)
+		return "", "", false
+	}
+	line = strings.TrimSpace(line)
+
+	// A command is a word (or a single non-letter like / or ?), then its argument
+	if line != "" && !isDigit(line[0]) {
+		if line[0] < 'a' || line[0] > 'z' {
+			return line[:1], strings.TrimSpace(line[1:]), true
+		}
+		cmd, arg, _ = strings.Cut(line, " ")
+		return cmd, strings.TrimSpace(arg), true
+	}
+	return "", line, true
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+// numbers parses space- or comma-separated 1-based numbers up to n
+func numbers(arg string, n int) ([]int, error) {
+	var result []int
+	for _, f := range strings.FieldsFunc(arg, func(r rune) bool { return r == ' ' || r == ',' }) {
+		i, err := strconv.Atoi(f)
+		if err != nil || i < 1 || i > n {
+			return nil, fmt.Errorf("no item %q", f)
+		}
+		result = append(result, i)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("which one? (give a number)")
+	}
+	return result, nil
+}
+
+func (p *promptFrontend) authors(list *authorList) (string, string, []string) {
+	// There's no list to update as it's counted, so wait for all of it
+	input, currentDate, stream := list.input, list.date, list.stream
+	if input == "" && stream != nil {
+		fmt.Fprintln(p.out, "Counting commits…")
+		for stream.live() {
+			time.Sleep(streamInterval)
+		}
+		entries, _, _, _ := stream.snapshot()
+		input = formatEntries(entries)
+	}
+	lines := strings.Split(strings.TrimRight(input, "\n"), "\n")
+	if input == "" {
+		lines = nil
+	}
+
+	selected := make(map[int]bool)
+	filter := ""
+	show := func() {
+		if currentDate != "" {
+			fmt.Fprintf(p.out, "\n%sShowing commits since %s%s%s\n", colorYellow, colorWhite, currentDate, colorReset)
+		} else {
+			fmt.Fprintf(p.out, "\n%sShowing full history%s\n", colorYellow, colorReset)
+		}
+		if stream != nil && stream.failed() != nil {
+			fmt.Fprintf(p.out, "%s%v%s\n", colorRed, stream.failed(), colorReset)
+		} else if len(lines) == 0 {
+			fmt.Fprintf(p.out, "%sNo commits match%s\n", colorYellow, colorReset)
+		}
+		for i, line := range lines {
+			if filter != "" && !strings.Contains(strings.ToLower(stripANSI(line)), strings.ToLower(filter)) {
+				continue
+			}
+			marker := "  "
+			if selected[i+1] {
+				marker = colorGreen + "✓ " + colorReset
+			}
+			fmt.Fprintln(p.out, marker+line)
+		}
+		fmt.Fprintf(p.out, "%s? for help%s\n", colorCyan, colorReset)
+	}
+	emails := func(ns []int) []string {
+		var result []string
+		for _, n := range ns {
+			result = append(result, lineEmail(lines[n-1]))
+		}
+		return result
+	}
+	selections := func() []string {
+		var result []string
+		for i, line := range lines {
+			if selected[i+1] {
+				result = append(result, stripANSI(line))
+			}
+		}
+		return result
+	}
+
+	show()
+	for {
+		cmd, arg, ok := p.readCommand("Authors")
+		if !ok {
+			return "back", "", nil
+		}
+
+		// Commands that take author numbers
+		var ns []int
+		switch cmd {
+		case "", "d", "w", "p", "h", "s":
+			var err error
+			if ns, err = numbers(arg, len(lines)); err != nil {
+				if cmd == "" && arg == "" {
+					continue
+				}
+				fmt.Fprintln(p.out, err)
+				continue
+			}
+		}
+
+		switch cmd {
+		case "":
+			p.run(false, append([]string{"_preview"}, emails(ns)...)...)
+		case "d":
+			p.run(true, append([]string{"_diffs"}, emails(ns)...)...)
+		case "w":
+			p.run(false, "_browser", emails(ns)[0])
+		case "p":
+			p.run(false, append([]string{"_prs"}, emails(ns)...)...)
+		case "h":
+			p.run(false, append([]string{"_punchcard"}, emails(ns)...)...)
+		case "c":
+			p.run(false, "_churn")
+		case "s":
+			for _, n := range ns {
+				selected[n] = !selected[n]
+			}
+			show()
+		case "/":
+			filter = arg
+			show()
+		case "f":
+			if arg == "" {
+				return "dates", "", selections()
+			}
+			if err := checkDate(arg); err != nil {
+				fmt.Fprintln(p.out, err)
+				continue
+			}
+			return "ctrl-o", arg, selections()
+		case "r":
+			if !hasRemoteChoice(remotes) {
+				fmt.Fprintln(p.out, "All remotes point at the same repository")
+				continue
+			}
+			return "remote", "", selections()
+		case "b":
+			return "back", "", selections()
+		case "q":
+			return "quit", "", selections()
+		case "?", "help":
+			fmt.Fprint(p.out, promptHelp)
+		default:
+			fmt.Fprintf(p.out, "Unknown command %q (? for help)\n", cmd)
+		}
+	}
+}
+
+func (p *promptFrontend) choose(c choice) (int, bool) {
+	show := func() {
+		fmt.Fprintf(p.out, "\n%s%s%s\n", colorYellow, c.header, colorReset)
+		for i, item := range c.items {
+			fmt.Fprintf(p.out, "%4d  %s\n", i+1, item)
+		}
+		if c.preview != "" {
+			fmt.Fprintln(p.out, `N: choose, "v N": view details, "l": list again, "b": back`)
+		} else {
+			fmt.Fprintln(p.out, `N: choose, "l": list again, "b": back`)
+		}
+	}
+
+	show()
+	for {
+		cmd, arg, ok := p.readCommand(c.prompt)
+		if !ok {
+			return 0, false
+		}
+		switch cmd {
+		case "":
+			ns, err := numbers(arg, len(c.items))
+			if err != nil {
+				if arg != "" {
+					fmt.Fprintln(p.out, err)
+				}
+				continue
+			}
+			return ns[0] - 1, true
+		case "v":
+			ns, err := numbers(arg, len(c.items))
+			if err != nil || c.preview == "" || ns[0] > len(c.keys) {
+				fmt.Fprintln(p.out, "Nothing to show")
+				continue
+			}
+			p.run(false, c.preview, c.keys[ns[0]-1])
+		case "l":
+			show()
+		case "b", "q":
+			return 0, false
+		default:
+			fmt.Fprintf(p.out, "Unknown command %q\n", cmd)
+		}
+	}
+}
+
+// compare prints the comparison, as the result (to stdout), since there's
+// no screen to re-sort it in
+func (p *promptFrontend) compare(c comparison) (string, []string) {
+	fmt.Printf("%s  vs.  %s\n%s\n", c.ranges[0], c.ranges[1], compareColumns)
+	for _, line := range c.lines {
+		fmt.Println(stripANSI(line))
+	}
+	return "", nil
+}
+
+// run runs one of our subcommands, showing its output (through a pager, if
+// paged is set and one is available)
+func (p *promptFrontend) run(paged bool, args ...string) {
+	cmd := exec.Command(selfPath, args...)
+	cmd.Env = subcommandEnv()
+	if !paged {
+		cmd.Stdout = p.out
+		cmd.Stderr = p.out
+		cmd.Run()
+		return
+	}
+	runPaged(cmd, p.out)
+}
+
+// runPaged runs cmd with its output piped through less, if there is one,
+// and reports whether it was
+func runPaged(cmd *exec.Cmd, out io.Writer) bool {
+	cmd.Stderr = out
+	pager, err := exec.LookPath("less")
+	if err != nil {
+		cmd.Stdout = out
+		cmd.Run()
+		return false
+	}
+	// Only the two commands keep the pipe open, so that cmd gets a broken
+	// pipe (rather than blocking) when less is quit early
+	r, w, err := os.Pipe()
+	if err != nil {
+		return false
+	}
+	cmd.Stdout = w
+	less := exec.Command(pager, "-R")
+	less.Stdin = r
+	less.Stdout = out
+	less.Stderr = out
+	err = cmd.Start()
+	w.Close()
+	if err == nil {
+		err = less.Start()
+	}
+	r.Close()
+	if err != nil {
+		return false
+	}
+	less.Wait()
+	cmd.Wait()
+	return true
+}
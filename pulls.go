@@ -0,0 +1,567 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// How many commits to look up per GraphQL request
+	pullsBatchSize = 50
+
+	// Most recent commits to find pull requests for; older ones are skipped
+	maxPullCommits = 200
+)
+
+// pullRequest is a pull request that a commit is associated with
+type pullRequest struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"` // OPEN, CLOSED or MERGED
+	URL    string `json:"url"`
+	Author string `json:"author"` // Login
+}
+
+// Subcommand: _prs
+func runPullsSubcommand(args []string) {
+	parseArgs(nil) // Load the state from the control server
+
+	if len(args) < 1 {
+		return
+	}
+	writePulls(os.Stdout, args, dateFilter)
+}
+
+// writePulls writes the pull requests for the authors' commits since
+// sinceDate, as shown in the preview
+func writePulls(w io.Writer, args []string, sinceDate string) {
+	fmt.Fprint(w, "\n\n")
+	if forgeKind != forgeGitHub || orgAndRepo == "" {
+		fmt.Fprintln(w, colorYellow+"Pull requests can only be looked up for GitHub repos"+colorReset)
+		return
+	}
+
+	shas, _ := previewCommits(args, sinceDate)
+	if len(shas) == 0 {
+		fmt.Fprintln(w, colorYellow+"No commits"+colorReset)
+		return
+	}
+	truncated := len(shas) > maxPullCommits
+	if truncated {
+		shas = shas[:maxPullCommits]
+	}
+
+	byCommit, err := associatedPulls(shas)
+	if err != nil {
+		fmt.Fprintf(w, "%sCould not look up pull requests: %v%s\n", colorYellow, err, colorReset)
+		return
+	}
+
+	prs := uniquePulls(byCommit)
+	fmt.Fprintf(w, "%sPull requests for %d commits%s", colorBoldCyan, len(shas), colorReset)
+	if truncated {
+		fmt.Fprintf(w, " %s(most recent %d only)%s", colorCyan, maxPullCommits, colorReset)
+	}
+	fmt.Fprint(w, "\n\n")
+	if len(prs) == 0 {
+		fmt.Fprintln(w, colorYellow+"None found"+colorReset)
+	}
+	fmt.Fprint(w, formatPulls(prs))
+	fmt.Fprintln(w, "\n"+colorCyan+"Press Alt-P again to return to commit preview"+colorReset)
+}
+
+// associatedPulls returns the pull requests associated with each commit,
+// looking up only those that aren't cached yet. Commits with an open pull
+// request aren't cached, as it can still be merged, closed or renamed.
+func associatedPulls(shas []string) (map[string][]pullRequest, error) {
+	cache := loadCommitCache[[]pullRequest]("associated-pulls.json")
+	found := make(map[string][]pullRequest)
+	var missing []string
+	for _, sha := range shas {
+		if prs, ok := cache.lookup(sha); ok {
+			found[sha] = prs
+		} else {
+			missing = append(missing, sha)
+		}
+	}
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	byCommit, err := pullsForCommits(missing)
+	if err != nil {
+		return nil, err
+	}
+	settled := make(map[string][]pullRequest)
+	for _, sha := range missing {
+		prs := byCommit[sha]
+		found[sha] = prs
+		if !slices.ContainsFunc(prs, func(pr pullRequest) bool { return pr.State == "OPEN" }) {
+			settled[sha] = prs
+		}
+	}
+	cache.store(settled)
+	return found, nil
+}
+
+// pullsForCommits looks up the pull requests associated with each commit,
+// from the JSON file named by GH_SHORTLOG_PR_FIXTURE if set (for testing
+// without network access), or else with batched GraphQL queries via gh
+func pullsForCommits(shas []string) (map[string][]pullRequest, error) {
+	if fixture := os.Getenv("GH_SHORTLOG_PR_FIXTURE"); fixture != "" {
+		return loadPullsFixture(fixture, shas)
+	}
+
+	found := make(map[string][]pullRequest)
+	for start := 0; start < len(shas); start += pullsBatchSize {
+		end := min(start+pullsBatchSize, len(shas))
+		batch := shas[start:end]
+
+		org, repo, _ := strings.Cut(orgAndRepo, "/")
+		out, err := ghAPICommand("graphql",
+			"-f", "query="+pullsQuery(batch),
+			"-f", "owner="+org,
+			"-f", "name="+repo).Output()
+		if err != nil {
+			return nil, err
+		}
+		prs, err := parsePullsResponse(out, batch)
+		if err != nil {
+			return nil, err
+		}
+		for sha, list := range prs {
+			found[sha] = list
+		}
+	}
+	return found, nil
+}
+
+// loadPullsFixture reads a {"<sha>": [<pullRequest>...]} JSON file
+func loadPullsFixture(path string, shas []string) (map[string][]pullRequest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string][]pullRequest)
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	found := make(map[string][]pullRequest)
+	for _, sha := range shas {
+		if prs, ok := all[sha]; ok {
+			found[sha] = prs
+		}
+	}
+	return found, nil
+}
+
+// pullsQuery builds a GraphQL query with one aliased associatedPullRequests
+// lookup per commit
+func pullsQuery(shas []string) string {
+	var q strings.Builder
+	q.WriteString("query($owner: String!, $name: String!) { repository(owner: $owner, name: $name) {")
+	for i, sha := range shas {
+		fmt.Fprintf(&q, " c%d: object(oid: %s) { ... on Commit { associatedPullRequests(first: 5) { nodes { number title state url author { login } } } } }",
+			i, strconv.Quote(sha))
+	}
+	q.WriteString(" } }")
+	return q.String()
+}
+
+// parsePullsResponse maps the aliased results of pullsQuery back to commits
+func parsePullsResponse(data []byte, shas []string) (map[string][]pullRequest, error) {
+	type commit struct {
+		AssociatedPullRequests struct {
+			Nodes []struct {
+				Number int    `json:"number"`
+				Title  string `json:"title"`
+				State  string `json:"state"`
+				URL    string `json:"url"`
+				Author *struct {
+					Login string `json:"login"`
+				} `json:"author"`
+			} `json:"nodes"`
+		} `json:"associatedPullRequests"`
+	}
+	var resp struct {
+		Data struct {
+			Repository map[string]*commit `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+
+	found := make(map[string][]pullRequest)
+	for i, sha := range shas {
+		c := resp.Data.Repository["c"+strconv.Itoa(i)]
+		if c == nil {
+			continue
+		}
+		for _, n := range c.AssociatedPullRequests.Nodes {
+			pr := pullRequest{Number: n.Number, Title: n.Title, State: n.State, URL: n.URL}
+			if n.Author != nil {
+				pr.Author = n.Author.Login
+			}
+			found[sha] = append(found[sha], pr)
+		}
+	}
+	return found, nil
+}
+
+// uniquePulls returns the distinct pull requests in byCommit, newest first
+func uniquePulls(byCommit map[string][]pullRequest) []pullRequest {
+	seen := make(map[int]bool)
+	var prs []pullRequest
+	for _, list := range byCommit {
+		for _, pr := range list {
+			if !seen[pr.Number] {
+				seen[pr.Number] = true
+				prs = append(prs, pr)
+			}
+		}
+	}
+	sort.Slice(prs, func(i, j int) bool { return prs[i].Number > prs[j].Number })
+	return prs
+}
+
+// formatPulls renders pull requests as colorized lines for the preview
+func formatPulls(prs []pullRequest) string {
+	var result strings.Builder
+	for _, pr := range prs {
+		state := strings.ToLower(pr.State)
+		stateColor := colorGreen
+		if pr.State == "CLOSED" {
+			stateColor = colorYellow
+		}
+		fmt.Fprintf(&result, "%s#%d%s  %s%-6s%s  %s%s%s",
+			colorWhite, pr.Number, colorReset,
+			stateColor, state, colorReset,
+			colorWhite, pr.Title, colorReset)
+		if pr.Author != "" {
+			fmt.Fprintf(&result, "  %s@%s%s", colorYellow, pr.Author, colorReset)
+		}
+		fmt.Fprintf(&result, "\n      %s%s%s\n", colorCyan, pr.URL, colorReset)
+	}
+	return result.String()
+}
+
+// Commits to ask the GitHub API about per preview, if their pull request
+// can't be found from the history itself
+const maxAPIPullCommits = pullsBatchSize
+
+// commitPull is the pull request that a commit was merged through
+type commitPull struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+}
+
+var (
+	// Squash merges: "Fix the thing (#123)"
+	squashPullRe = regexp.MustCompile(`\(#(\d+)\)\s*$`)
+	// GitHub merge commits: "Merge pull request #123 from user/branch"
+	mergePullRe = regexp.MustCompile(`^Merge pull request #(\d+) from `)
+	// Bitbucket merge commits: "Merged in branch (pull request #123)"
+	bitbucketPullRe = regexp.MustCompile(`\(pull request #(\d+)\)`)
+	// GitLab merge commits: "... See merge request group/repo!123"
+	gitlabMergeRequestRe = regexp.MustCompile(`See merge request \S*!(\d+)`)
+)
+
+// pullFromMessage detects the pull request a commit message says it's for
+func pullFromMessage(subject, body string) (commitPull, bool) {
+	// Merge commits have the PR title as the first line of the body
+	bodyTitle, _, _ := strings.Cut(strings.TrimSpace(body), "\n")
+	if m := mergePullRe.FindStringSubmatch(subject); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return commitPull{n, bodyTitle}, true
+	}
+	if m := gitlabMergeRequestRe.FindStringSubmatch(body); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if gitlabMergeRequestRe.MatchString(bodyTitle) {
+			bodyTitle = ""
+		}
+		return commitPull{n, bodyTitle}, true
+	}
+	if m := bitbucketPullRe.FindStringSubmatch(subject); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return commitPull{n, bodyTitle}, true
+	}
+	if m := squashPullRe.FindStringSubmatch(subject); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return commitPull{n, strings.TrimSpace(squashPullRe.ReplaceAllString(subject, ""))}, true
+	}
+	return commitPull{}, false
+}
+
+// logCommit is a commit as read by readLogCommits
+type logCommit struct {
+	sha     string
+	parents []string
+	subject string
+	body    string
+}
+
+// Format for readLogCommits: fields separated by US, records by RS
+const logCommitFormat = "--format=%H%x1f%P%x1f%s%x1f%b%x1e"
+
+// parseLogCommits parses git log output in logCommitFormat
+func parseLogCommits(out string) []logCommit {
+	var commits []logCommit
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		commits = append(commits, logCommit{
+			sha:     fields[0],
+			parents: strings.Fields(fields[1]),
+			subject: fields[2],
+			body:    fields[3],
+		})
+	}
+	return commits
+}
+
+// mergedPulls maps commits to the pull request they were merged through,
+// given the history (newest first, starting at the tip) of a window of
+// time: squash-merged commits say so in their subject, and commits merged
+// with a merge commit are found by walking the merged side of each pull
+// request merge on the mainline (the first-parent chain from the tip)
+func mergedPulls(commits []logCommit) map[string]commitPull {
+	pulls := make(map[string]commitPull)
+	if len(commits) == 0 {
+		return pulls
+	}
+
+	byHash := make(map[string]logCommit)
+	for _, c := range commits {
+		byHash[c.sha] = c
+		if pr, ok := pullFromMessage(c.subject, c.body); ok {
+			pulls[c.sha] = pr
+		}
+	}
+
+	var mainline []logCommit
+	onMainline := make(map[string]bool)
+	for c, ok := commits[0], true; ok; {
+		mainline = append(mainline, c)
+		onMainline[c.sha] = true
+		if len(c.parents) == 0 {
+			break
+		}
+		c, ok = byHash[c.parents[0]]
+	}
+
+	// Oldest merges first, so each commit gets the pull request that
+	// first brought it into the mainline
+	for i := len(mainline) - 1; i >= 0; i-- {
+		merge := mainline[i]
+		pr, ok := pulls[merge.sha]
+		if !ok || len(merge.parents) < 2 {
+			continue
+		}
+		stack := append([]string(nil), merge.parents[1:]...)
+		for len(stack) > 0 {
+			sha := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			c, inWindow := byHash[sha]
+			if !inWindow || onMainline[sha] {
+				continue
+			}
+			if _, done := pulls[sha]; done {
+				continue
+			}
+			pulls[sha] = pr
+			stack = append(stack, c.parents...)
+		}
+	}
+	return pulls
+}
+
+// commitPulls finds the pull requests that shas (newest first, all from
+// the current range) were merged through; the history is searched back to
+// the oldest of them, and GitHub is asked about the rest
+func commitPulls(shas []string, oldest time.Time) map[string]commitPull {
+	logArgs := []string{"log", logCommitFormat, "--since=" + oldest.Format(time.RFC3339)}
+	logArgs = append(logArgs, revisionOnlyArgs()...)
+	out, err := gitCommand(logArgs...).Output()
+	if err != nil {
+		return nil
+	}
+	pulls := mergedPulls(parseLogCommits(string(out)))
+
+	if forgeKind != forgeGitHub || orgAndRepo == "" {
+		return pulls
+	}
+
+	// Look up the rest with the API. Once a commit's pull request is merged,
+	// that's what it was merged through for good, so only those are cached;
+	// commits without one (yet) are asked about again next time. (Earlier
+	// versions cached those too, as a zero Number, which is ignored.)
+	cache := loadPullCache()
+	var unresolved []string
+	for _, sha := range shas {
+		if _, ok := pulls[sha]; ok {
+			continue
+		}
+		if pr, ok := cache.lookup(sha); ok && pr.Number != 0 {
+			pulls[sha] = pr
+		} else if len(unresolved) < maxAPIPullCommits {
+			unresolved = append(unresolved, sha)
+		}
+	}
+	if len(unresolved) == 0 {
+		return pulls
+	}
+	byCommit, err := pullsForCommits(unresolved)
+	if err != nil {
+		return pulls
+	}
+	found := make(map[string]commitPull)
+	for _, sha := range unresolved {
+		// The first merged PR is the one the commit went in through; open
+		// or closed ones aren't what it was merged through
+		for _, p := range byCommit[sha] {
+			if p.State == "MERGED" {
+				found[sha] = commitPull{p.Number, p.Title}
+				pulls[sha] = found[sha]
+				break
+			}
+		}
+	}
+	cache.store(found)
+	return pulls
+}
+
+// revisionOnlyArgs returns the revisions from gitArgs (or HEAD), without
+// the options and paths, which would limit or simplify the history
+func revisionOnlyArgs() []string {
+	var revs []string
+	for _, arg := range gitArgs {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			revs = append(revs, arg)
+		}
+	}
+	if len(revs) == 0 {
+		return []string{"HEAD"}
+	}
+	return revs
+}
+
+// annotatePulls adds a line with the pull request after each "commit <hash>"
+// line in git log output (before the hashes get replaced with URLs)
+func annotatePulls(output string, pulls map[string]commitPull, forge forgeRepo) string {
+	commitLineRe := regexp.MustCompile(`^commit ([0-9a-f]{40})`)
+
+	var result strings.Builder
+	for _, line := range strings.SplitAfter(output, "\n") {
+		result.WriteString(line)
+		m := commitLineRe.FindStringSubmatch(stripANSI(line))
+		if m == nil {
+			continue
+		}
+		pr, ok := pulls[m[1]]
+		if !ok || pr.Number == 0 {
+			continue
+		}
+		link := "#" + strconv.Itoa(pr.Number)
+		if forge.path != "" {
+			link = forge.pullURL(pr.Number)
+		}
+		fmt.Fprintf(&result, "PR:         %s%s%s  %s\n", colorCyan, link, colorReset, pr.Title)
+	}
+	return result.String()
+}
+
+// commitCache is a persistent commit→T map for one repo, for what can't
+// change any more once it's known (callers only store such entries)
+type commitCache[T any] struct {
+	path    string
+	repo    string // host/org/repo
+	entries map[string]T
+}
+
+// pullCache records the pull request each commit was merged through
+type pullCache = commitCache[commitPull]
+
+// loadPullCache reads the cached pull requests for the current repo
+func loadPullCache() *pullCache {
+	return loadCommitCache[commitPull]("pulls.json")
+}
+
+// loadCommitCache reads the current repo's entries from the cache file
+// named name in the user cache dir
+func loadCommitCache[T any](name string) *commitCache[T] {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return nil
+	}
+	c := &commitCache[T]{
+		path:    filepath.Join(dir, "gh-shortlog", name),
+		repo:    githubHost() + "/" + orgAndRepo,
+		entries: make(map[string]T),
+	}
+	if repos, err := c.readFile(); err == nil && repos[c.repo] != nil {
+		c.entries = repos[c.repo]
+	}
+	return c
+}
+
+// readFile reads the cached entries for all repos
+func (c *commitCache[T]) readFile() (map[string]map[string]T, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, err
+	}
+	repos := make(map[string]map[string]T)
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+// lookup returns the cached entry for sha, and whether it was cached
+func (c *commitCache[T]) lookup(sha string) (T, bool) {
+	if c == nil {
+		var zero T
+		return zero, false
+	}
+	entry, ok := c.entries[sha]
+	return entry, ok
+}
+
+// store records entries (keyed by commit) and writes the cache file
+func (c *commitCache[T]) store(found map[string]T) error {
+	if c == nil || len(found) == 0 {
+		return nil
+	}
+	repos, err := c.readFile()
+	if err != nil {
+		repos = make(map[string]map[string]T)
+	}
+	if repos[c.repo] == nil {
+		repos[c.repo] = make(map[string]T)
+	}
+	for sha, entry := range found {
+		repos[c.repo][sha] = entry
+		c.entries[sha] = entry
+	}
+
+	data, err := json.Marshal(repos)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.path, data)
+}
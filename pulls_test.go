@@ -0,0 +1,279 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPullsQuery(t *testing.T) {
+	q := pullsQuery([]string{"abc123", "def456"})
+	for _, want := range []string{`c0: object(oid: "abc123")`, `c1: object(oid: "def456")`, "associatedPullRequests"} {
+		if !strings.Contains(q, want) {
+			t.Errorf("query missing %q:\n%s", want, q)
+		}
+	}
+	if strings.Count(q, "{") != strings.Count(q, "}") {
+		t.Errorf("unbalanced braces in query:\n%s", q)
+	}
+}
+
+func TestParsePullsResponse(t *testing.T) {
+	response := `{"data": {"repository": {
+		"c0": {"associatedPullRequests": {"nodes": [
+			{"number": 12, "title": "Add feature", "state": "MERGED", "url": "https://github.com/org/repo/pull/12", "author": {"login": "jane"}}
+		]}},
+		"c1": {"associatedPullRequests": {"nodes": []}},
+		"c2": null
+	}}}`
+
+	found, err := parsePullsResponse([]byte(response), []string{"aaa", "bbb", "ccc"})
+	if err != nil {
+		t.Fatalf("parsePullsResponse: %v", err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("expected pull requests for 1 commit, got %v", found)
+	}
+	want := pullRequest{12, "Add feature", "MERGED", "https://github.com/org/repo/pull/12", "jane"}
+	if prs := found["aaa"]; len(prs) != 1 || prs[0] != want {
+		t.Errorf("found[aaa] = %+v, want [%+v]", prs, want)
+	}
+}
+
+func TestUniquePulls(t *testing.T) {
+	prs := uniquePulls(map[string][]pullRequest{
+		"aaa": {{Number: 3}, {Number: 7}},
+		"bbb": {{Number: 7}},
+		"ccc": {{Number: 5}},
+	})
+	var numbers []int
+	for _, pr := range prs {
+		numbers = append(numbers, pr.Number)
+	}
+	if len(numbers) != 3 || numbers[0] != 7 || numbers[1] != 5 || numbers[2] != 3 {
+		t.Errorf("uniquePulls() numbers = %v, want [7 5 3]", numbers)
+	}
+}
+
+func TestPullsFromFixture(t *testing.T) {
+	dir, git := testRepo(t)
+	oldWorkDir, oldGitArgs := workDir, gitArgs
+	defer func() { workDir, gitArgs = oldWorkDir, oldGitArgs }()
+	workDir = dir
+	gitArgs = nil
+
+	git("commit", "-q", "--allow-empty", "-m", "one")
+	git("commit", "-q", "--allow-empty", "--author=John Doe <john@example.com>", "-m", "two")
+
+	shas, _ := previewCommits([]string{"<jane@example.com>"}, "")
+	if len(shas) != 1 {
+		t.Fatalf("expected 1 commit by jane, got %v", shas)
+	}
+
+	fixture := filepath.Join(t.TempDir(), "prs.json")
+	data := `{"` + shas[0] + `": [{"number": 42, "title": "First", "state": "MERGED", "url": "https://github.com/org/repo/pull/42", "author": "jane"}],
+		"0000000000000000000000000000000000000000": [{"number": 1}]}`
+	if err := os.WriteFile(fixture, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_SHORTLOG_PR_FIXTURE", fixture)
+
+	found, err := pullsForCommits(shas)
+	if err != nil {
+		t.Fatalf("pullsForCommits: %v", err)
+	}
+	prs := uniquePulls(found)
+	if len(prs) != 1 || prs[0].Number != 42 {
+		t.Fatalf("expected PR #42, got %+v", prs)
+	}
+	if out := formatPulls(prs); !strings.Contains(out, "#42") || !strings.Contains(out, "@jane") {
+		t.Errorf("formatPulls() = %q", out)
+	}
+}
+
+func TestAssociatedPullsCache(t *testing.T) {
+	oldOrgRepo := orgAndRepo
+	defer func() { orgAndRepo = oldOrgRepo }()
+	orgAndRepo = "org/repo"
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	merged, open, none := strings.Repeat("a", 40), strings.Repeat("b", 40), strings.Repeat("c", 40)
+	fixture := filepath.Join(t.TempDir(), "prs.json")
+	data := `{"` + merged + `": [{"number": 1, "state": "MERGED"}], "` + open + `": [{"number": 2, "state": "OPEN"}]}`
+	if err := os.WriteFile(fixture, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_SHORTLOG_PR_FIXTURE", fixture)
+	shas := []string{merged, open, none}
+	if found, err := associatedPulls(shas); err != nil || len(found[merged]) != 1 || len(found[open]) != 1 {
+		t.Fatalf("associatedPulls = %v, %v", found, err)
+	}
+
+	// Later lookups only ask about commits with open pull requests
+	if err := os.WriteFile(fixture, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	found, err := associatedPulls(shas)
+	if err != nil || len(found[merged]) != 1 || len(found[open]) != 0 || len(found[none]) != 0 {
+		t.Errorf("after caching: associatedPulls = %v, %v", found, err)
+	}
+}
+
+func TestPullFromMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		subject string
+		body    string
+		want    commitPull
+		wantOK  bool
+	}{
+		{"squash merge", "Fix the parser (#123)", "", commitPull{123, "Fix the parser"}, true},
+		{"GitHub merge", "Merge pull request #45 from user/branch", "Add a feature\n\nMore details", commitPull{45, "Add a feature"}, true},
+		{"GitLab merge", "Merge branch 'feature' into 'main'", "Add a feature\n\nSee merge request group/repo!67", commitPull{67, "Add a feature"}, true},
+		{"Bitbucket merge", "Merged in feature (pull request #8)", "Add a feature", commitPull{8, "Add a feature"}, true},
+		{"issue reference", "Fix #123 in the parser", "", commitPull{}, false},
+		{"plain commit", "Fix the parser", "", commitPull{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := pullFromMessage(tt.subject, tt.body)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("pullFromMessage() = %+v, %v; want %+v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestMergedPulls(t *testing.T) {
+	// m2 merges PR #2 (b1, b2), which had main merged into it (via bm);
+	// s1 is a squash merge of PR #1; a0 is a direct commit on main
+	//
+	//   m2 ── s1 ── a0
+	//    └── b2 ── bm ── b1 ── a0
+	//               └── s1
+	commits := []logCommit{
+		{sha: "m2", parents: []string{"s1", "b2"}, subject: "Merge pull request #2 from user/b", body: "Feature B"},
+		{sha: "b2", parents: []string{"bm"}, subject: "More B"},
+		{sha: "bm", parents: []string{"b1", "s1"}, subject: "Merge branch 'main' into b"},
+		{sha: "s1", parents: []string{"a0"}, subject: "Feature A (#1)"},
+		{sha: "b1", parents: []string{"a0"}, subject: "Start B"},
+		{sha: "a0", subject: "Initial commit"},
+	}
+
+	pulls := mergedPulls(commits)
+	want := map[string]int{"m2": 2, "b2": 2, "bm": 2, "b1": 2, "s1": 1}
+	for sha, n := range want {
+		if pulls[sha].Number != n {
+			t.Errorf("pull for %s = %+v, want #%d", sha, pulls[sha], n)
+		}
+	}
+	if pr, ok := pulls["a0"]; ok {
+		t.Errorf("expected no pull for a0, got %+v", pr)
+	}
+	if pulls["b1"].Title != "Feature B" {
+		t.Errorf("title for b1 = %q, want %q", pulls["b1"].Title, "Feature B")
+	}
+}
+
+func TestAnnotatePulls(t *testing.T) {
+	sha := strings.Repeat("a", 40)
+	other := strings.Repeat("b", 40)
+	output := "\033[33mcommit " + sha + "\033[m\nAuthor:     Jane\n\n    Fix it (#7)\n\n" +
+		"\033[33mcommit " + other + "\033[m\nAuthor:     Jane\n"
+	pulls := map[string]commitPull{sha: {7, "Fix it"}, other: {}}
+
+	got := annotatePulls(output, pulls, forgeRepo{forgeGitHub, "github.com", "org/repo"})
+	lines := strings.Split(got, "\n")
+	if !strings.Contains(lines[1], "PR:") || !strings.Contains(lines[1], "https://github.com/org/repo/pull/7") {
+		t.Errorf("expected PR line after first commit line, got %q", lines[1])
+	}
+	if strings.Count(got, "PR:") != 1 {
+		t.Errorf("expected exactly one PR line, got:\n%s", got)
+	}
+}
+
+func TestCommitPullsFromHistory(t *testing.T) {
+	dir, git := testRepo(t)
+	oldWorkDir, oldGitArgs, oldKind := workDir, gitArgs, forgeKind
+	defer func() { workDir, gitArgs, forgeKind = oldWorkDir, oldGitArgs, oldKind }()
+	workDir = dir
+	gitArgs = nil
+	forgeKind = forgeGitLab // Keeps the GitHub API out of it
+
+	git("commit", "-q", "--allow-empty", "-m", "Initial commit")
+	git("checkout", "-q", "-b", "feature")
+	git("commit", "-q", "--allow-empty", "-m", "Work on the feature")
+	git("checkout", "-q", "main")
+	git("commit", "-q", "--allow-empty", "-m", "Squashed change (#3)")
+	git("merge", "-q", "--no-ff", "feature", "-m", "Merge pull request #4 from jane/feature", "-m", "The feature")
+
+	shas, oldest := previewCommits([]string{"<jane@example.com>"}, "")
+	if len(shas) != 4 {
+		t.Fatalf("expected 4 commits, got %v", shas)
+	}
+	pulls := commitPulls(shas, oldest)
+
+	subjects := make(map[string]int)
+	for _, sha := range shas {
+		subjects[git("log", "-1", "--format=%s", sha)] = pulls[sha].Number
+	}
+	want := map[string]int{
+		"Merge pull request #4 from jane/feature": 4,
+		"Squashed change (#3)":                    3,
+		"Work on the feature":                     4,
+		"Initial commit":                          0,
+	}
+	for subject, n := range want {
+		if subjects[subject] != n {
+			t.Errorf("pull for %q = #%d, want #%d", subject, subjects[subject], n)
+		}
+	}
+}
+
+func TestCommitPullsCachesMergedOnly(t *testing.T) {
+	dir, git := testRepo(t)
+	oldWorkDir, oldGitArgs, oldKind, oldOrgRepo := workDir, gitArgs, forgeKind, orgAndRepo
+	defer func() { workDir, gitArgs, forgeKind, orgAndRepo = oldWorkDir, oldGitArgs, oldKind, oldOrgRepo }()
+	workDir = dir
+	gitArgs = nil
+	forgeKind, orgAndRepo = forgeGitHub, "org/repo"
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	git("commit", "-q", "--allow-empty", "-m", "No pull request")
+	git("commit", "-q", "--allow-empty", "-m", "In an open pull request")
+	git("commit", "-q", "--allow-empty", "-m", "Merged")
+	shas, oldest := previewCommits([]string{"<jane@example.com>"}, "")
+	merged, open, none := shas[0], shas[1], shas[2]
+
+	fixture := filepath.Join(t.TempDir(), "prs.json")
+	data := `{"` + merged + `": [{"number": 5, "title": "Closed", "state": "CLOSED"}, {"number": 6, "title": "Merged", "state": "MERGED"}],
+		"` + open + `": [{"number": 7, "title": "Open", "state": "OPEN"}]}`
+	if err := os.WriteFile(fixture, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GH_SHORTLOG_PR_FIXTURE", fixture)
+
+	pulls := commitPulls(shas, oldest)
+	if pulls[merged] != (commitPull{6, "Merged"}) {
+		t.Errorf("pull for the merged commit = %+v, want #6", pulls[merged])
+	}
+	for _, sha := range []string{open, none} {
+		if pr, ok := pulls[sha]; ok {
+			t.Errorf("unexpected pull for an unmerged commit: %+v", pr)
+		}
+	}
+
+	cache := loadPullCache()
+	if _, ok := cache.lookup(merged); !ok {
+		t.Error("the merged commit's pull request wasn't cached")
+	}
+	for _, sha := range []string{open, none} {
+		if pr, ok := cache.lookup(sha); ok {
+			t.Errorf("unmerged commit cached as %+v", pr)
+		}
+	}
+}
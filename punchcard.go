@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Zone that the punchcard shows commit times in, set with --punchcard-tz;
+// empty for each commit's own (author's) time zone
+var punchcardZone string
+
+// Working hours, for the punchcard's summary of commits outside them
+const (
+	workdayStart = 8  // 08:00
+	workdayEnd   = 20 // 20:00
+)
+
+// Dots for a punchcard cell, from no commits to the busiest hour
+var punchcardDots = []string{"  ", "· ", "∙ ", "• ", "● "}
+
+// punchcard is commit counts by weekday (Monday first) and hour
+type punchcard [7][24]int
+
+// Subcommand: _punchcard
+func runPunchcardSubcommand(args []string) {
+	parseArgs(nil) // Load the state from the control server
+
+	if len(args) < 1 {
+		return
+	}
+	writePunchcard(os.Stdout, args, dateFilter)
+}
+
+// writePunchcard writes the punchcard of the authors' commits since
+// sinceDate, as shown in the preview
+func writePunchcard(w io.Writer, args []string, sinceDate string) {
+	loc, err := punchcardLocation(punchcardZone)
+	if err != nil {
+		fmt.Fprintf(w, "\nUnknown time zone %q: %v\n", punchcardZone, err)
+		return
+	}
+
+	logArgs := []string{"log", "--format=%aI"}
+	for _, author := range args {
+		logArgs = append(logArgs, "--author="+author)
+	}
+	if sinceDate != "" {
+		logArgs = append(logArgs, "--since="+sinceDate)
+	}
+	logArgs = append(logArgs, revisionArgs()...)
+
+	out, err := gitOutput(logArgs...)
+	if err != nil {
+		fmt.Fprintf(w, "\n%s%v%s\n", colorRed, err, colorReset)
+		return
+	}
+
+	zone := "author's time zone"
+	if loc != nil {
+		zone = loc.String()
+	}
+	fmt.Fprintf(w, "\n%sCommits by weekday and hour%s (%s)\n\n", colorBoldCyan, colorReset, zone)
+	fmt.Fprint(w, formatPunchcard(buildPunchcard(strings.Fields(string(out)), loc)))
+}
+
+// punchcardLocation returns the zone named by --punchcard-tz, or nil to use
+// each commit's own zone
+func punchcardLocation(name string) (*time.Location, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "local":
+		return time.Local, nil
+	default:
+		return time.LoadLocation(name)
+	}
+}
+
+// buildPunchcard counts ISO 8601 commit times by weekday and hour, in loc
+// (or, if loc is nil, in the zone of each time's own offset)
+func buildPunchcard(dates []string, loc *time.Location) punchcard {
+	var p punchcard
+	for _, d := range dates {
+		t, err := time.Parse(time.RFC3339, d)
+		if err != nil {
+			continue
+		}
+		if loc != nil {
+			t = t.In(loc)
+		}
+		day := (int(t.Weekday()) + 6) % 7 // Monday first
+		p[day][t.Hour()]++
+	}
+	return p
+}
+
+// formatPunchcard draws the 7×24 grid, with totals per day and a summary of
+// commits outside working hours and at weekends
+func formatPunchcard(p punchcard) string {
+	peak, total, offHours, weekend := 0, 0, 0, 0
+	for day, hours := range p {
+		for hour, n := range hours {
+			peak = max(peak, n)
+			total += n
+			if hour < workdayStart || hour >= workdayEnd {
+				offHours += n
+			}
+			if day >= 5 {
+				weekend += n
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("     ")
+	for hour := 0; hour < 24; hour += 3 {
+		fmt.Fprintf(&b, "%s%-6d%s", colorCyan, hour, colorReset)
+	}
+	b.WriteString("\n")
+
+	for day, hours := range p {
+		fmt.Fprintf(&b, "%s%-4s%s ", colorWhite, time.Weekday((day + 1) % 7).String()[:3], colorReset)
+		dayTotal := 0
+		for _, n := range hours {
+			dayTotal += n
+			level := 0
+			if n > 0 {
+				level = 1 + n*(len(punchcardDots)-2)/peak
+			}
+			fmt.Fprintf(&b, "%s%s%s", colorGreen, punchcardDots[level], colorReset)
+		}
+		fmt.Fprintf(&b, "  %4d\n", dayTotal)
+	}
+
+	if total > 0 {
+		fmt.Fprintf(&b, "\n%d commits; %s%d (%.0f%%)%s outside %02d:00–%02d:00, %s%d (%.0f%%)%s at weekends\n",
+			total,
+			colorYellow, offHours, 100*float64(offHours)/float64(total), colorReset, workdayStart, workdayEnd,
+			colorYellow, weekend, 100*float64(weekend)/float64(total), colorReset)
+	}
+	return b.String()
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildPunchcard(t *testing.T) {
+	dates := []string{
+		"2024-03-04T09:15:00+01:00", // Monday 09:xx in the author's zone
+		"2024-03-04T09:45:00+01:00",
+		"2024-03-09T23:30:00-08:00", // Saturday 23:xx, Sunday 07:xx in UTC
+		"not a date",
+	}
+
+	p := buildPunchcard(dates, nil)
+	if p[0][9] != 2 || p[5][23] != 1 {
+		t.Errorf("author zones: Monday 09 = %d, Saturday 23 = %d; want 2, 1", p[0][9], p[5][23])
+	}
+
+	p = buildPunchcard(dates, time.UTC)
+	if p[0][8] != 2 || p[6][7] != 1 || p[5][23] != 0 {
+		t.Errorf("UTC: Monday 08 = %d, Sunday 07 = %d, Saturday 23 = %d; want 2, 1, 0", p[0][8], p[6][7], p[5][23])
+	}
+}
+
+func TestPunchcardLocation(t *testing.T) {
+	if loc, err := punchcardLocation(""); loc != nil || err != nil {
+		t.Errorf("empty zone: got %v, %v; want nil, nil", loc, err)
+	}
+	if loc, err := punchcardLocation("UTC"); err != nil || loc.String() != "UTC" {
+		t.Errorf("UTC: got %v, %v", loc, err)
+	}
+	if _, err := punchcardLocation("Not/AZone"); err == nil {
+		t.Error("expected an error for an unknown zone")
+	}
+}
+
+func TestFormatPunchcard(t *testing.T) {
+	var p punchcard
+	p[0][9] = 4  // Monday 09:00
+	p[0][10] = 1 // Monday 10:00
+	p[5][23] = 2 // Saturday 23:00
+
+	lines := strings.Split(stripANSI(formatPunchcard(p)), "\n")
+	if !strings.HasPrefix(lines[0], "     0     3     6     9") {
+		t.Errorf("unexpected hours line: %q", lines[0])
+	}
+	monday := lines[1]
+	if !strings.HasPrefix(monday, "Mon  ") || !strings.HasSuffix(monday, "     5") {
+		t.Errorf("unexpected Monday line: %q", monday)
+	}
+	// Cells are 2 wide, so hour h is at 5 + 2h
+	if cell := []rune(monday)[5+2*9]; cell != '●' {
+		t.Errorf("busiest hour drawn as %q, want ●", cell)
+	}
+	if cell := []rune(monday)[5+2*10]; cell != '·' {
+		t.Errorf("quietest hour drawn as %q, want ·", cell)
+	}
+	if !strings.HasPrefix(lines[7], "Sun") {
+		t.Errorf("expected Sunday last, got %q", lines[7])
+	}
+
+	summary := lines[len(lines)-2]
+	if summary != "7 commits; 2 (29%) outside 08:00–20:00, 2 (29%) at weekends" {
+		t.Errorf("unexpected summary: %q", summary)
+	}
+}
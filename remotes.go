@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// remoteInfo is a git remote, and the forge repo its URL points at (if any)
+type remoteInfo struct {
+	name string
+	url  string
+	repo forgeRepo
+	ok   bool // Whether url could be parsed as a forge repo
+}
+
+// All remotes of the repo, as found by setupGitHubInfo
+var remotes []remoteInfo
+
+// listRemotes returns the repo's remotes, in the order git config lists them
+func listRemotes(forgeKind string) []remoteInfo {
+	out, err := gitCommand("config", "--get-regexp", `^remote\..*\.url$`).Output()
+	if err != nil {
+		return nil
+	}
+
+	var result []remoteInfo
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		key, remoteURL, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(key, "remote."), ".url")
+		repo, ok := parseRemoteURL(remoteURL, forgeKind)
+		result = append(result, remoteInfo{name: name, url: remoteURL, repo: repo, ok: ok})
+	}
+	return result
+}
+
+// trackingRemote returns the remote that the current branch tracks, if any
+func trackingRemote() string {
+	branch, err := gitCommand("symbolic-ref", "--short", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	out, err := gitCommand("config", "--get", "branch."+strings.TrimSpace(string(branch))+".remote").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// defaultRemote picks the remote whose forge repo links are built for:
+// the one named with --remote, else the remote that the current branch
+// tracks, upstream, origin, or the first one, in that order of preference
+func defaultRemote(remotes []remoteInfo, requested, tracking string) (remoteInfo, error) {
+	byName := make(map[string]remoteInfo)
+	for _, r := range remotes {
+		byName[r.name] = r
+	}
+
+	if requested != "" {
+		r, found := byName[requested]
+		if !found {
+			return remoteInfo{}, fmt.Errorf("no remote named %q", requested)
+		}
+		return r, nil
+	}
+
+	for _, name := range []string{tracking, "upstream", "origin"} {
+		if r, found := byName[name]; found && r.ok {
+			return r, nil
+		}
+	}
+	for _, r := range remotes {
+		if r.ok {
+			return r, nil
+		}
+	}
+	return remoteInfo{}, fmt.Errorf("no remote with a forge URL")
+}
+
+// useRemote makes r the remote that all links are built for
+func useRemote(r remoteInfo) {
+	remoteName = r.name
+	if !r.ok {
+		forgeKind, forgeHost, orgAndRepo, baseURL = "", "", "", ""
+		return
+	}
+	forgeKind = r.repo.kind
+	forgeHost = r.repo.host
+	orgAndRepo = r.repo.path
+	baseURL = r.repo.commitURLBase()
+}
+
+// hasRemoteChoice reports whether remotes point at more than one forge
+// repo, in which case the user gets to pick which one links go to
+func hasRemoteChoice(remotes []remoteInfo) bool {
+	seen := make(map[forgeRepo]bool)
+	for _, r := range remotes {
+		if r.ok {
+			seen[r.repo] = true
+		}
+	}
+	return len(seen) > 1
+}
+
+// pickRemote lets the user choose a remote (in a nested fzf, with fzf)
+func pickRemote(remotes []remoteInfo) (remoteInfo, bool) {
+	maxName := 0
+	for _, r := range remotes {
+		maxName = max(maxName, len(r.name))
+	}
+
+	var choices []remoteInfo
+	c := choice{header: "Choose the remote to build links for", prompt: "Remote"}
+	for _, r := range remotes {
+		if !r.ok {
+			continue
+		}
+		marker := "  "
+		if r.name == remoteName {
+			marker = "▶ "
+		}
+		c.items = append(c.items, fmt.Sprintf("%s%s%-*s%s  %s%s%s",
+			marker, colorWhite, maxName, r.name, colorReset, colorCyan, r.repo.webURL(), colorReset))
+		choices = append(choices, r)
+	}
+
+	i, ok := ui.choose(c)
+	if !ok {
+		return remoteInfo{}, false
+	}
+	return choices[i], true
+}
+
+var ansiRe = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// stripANSI removes color codes from s
+func stripANSI(s string) string {
+	return ansiRe.ReplaceAllString(s, "")
+}
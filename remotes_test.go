@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+)
+
+func testRemote(name, url string) remoteInfo {
+	repo, ok := parseRemoteURL(url, "")
+	return remoteInfo{name: name, url: url, repo: repo, ok: ok}
+}
+
+func TestDefaultRemote(t *testing.T) {
+	gh := testRemote("gh", "git@github.com:org/repo.git")
+	mine := testRemote("mine", "git@gitlab.example.com:me/repo.git")
+	origin := testRemote("origin", "https://github.com/me/repo.git")
+	upstream := testRemote("upstream", "https://github.com/org/repo.git")
+	local := testRemote("backup", "/srv/git/repo.git")
+
+	tests := []struct {
+		name      string
+		remotes   []remoteInfo
+		requested string
+		tracking  string
+		want      string
+		wantErr   bool
+	}{
+		{"upstream before origin", []remoteInfo{origin, upstream}, "", "", "upstream", false},
+		{"requested wins", []remoteInfo{origin, upstream, gh}, "gh", "", "gh", false},
+		{"requested missing", []remoteInfo{origin}, "gh", "", "", true},
+		{"tracking remote", []remoteInfo{gh, mine}, "", "mine", "mine", false},
+		{"tracking remote before origin", []remoteInfo{origin, mine}, "", "mine", "mine", false},
+		{"tracking remote before upstream", []remoteInfo{origin, upstream, gh}, "", "gh", "gh", false},
+		{"first remote", []remoteInfo{gh, mine}, "", "", "gh", false},
+		{"skips non-forge remotes", []remoteInfo{local, mine}, "", "backup", "mine", false},
+		{"no forge remotes", []remoteInfo{local}, "", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := defaultRemote(tt.remotes, tt.requested, tt.tracking)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got remote %q", got.name)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.name != tt.want {
+				t.Errorf("defaultRemote() = %q, want %q", got.name, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasRemoteChoice(t *testing.T) {
+	origin := testRemote("origin", "https://github.com/org/repo.git")
+	sameRepo := testRemote("ssh", "git@github.com:org/repo.git")
+	fork := testRemote("mine", "git@github.com:me/repo.git")
+
+	if hasRemoteChoice([]remoteInfo{origin}) {
+		t.Error("expected no choice with a single remote")
+	}
+	if hasRemoteChoice([]remoteInfo{origin, sameRepo}) {
+		t.Error("expected no choice with remotes for the same repo")
+	}
+	if !hasRemoteChoice([]remoteInfo{origin, fork}) {
+		t.Error("expected a choice with remotes for different repos")
+	}
+}
+
+func TestListRemotes(t *testing.T) {
+	dir, git := testRepo(t)
+	oldWorkDir := workDir
+	defer func() { workDir = oldWorkDir }()
+	workDir = dir
+
+	git("remote", "add", "gh", "git@github.com:org/repo.git")
+	git("remote", "add", "mine", "https://gitlab.com/me/group/repo.git")
+	git("checkout", "-q", "-b", "topic")
+	git("config", "branch.topic.remote", "mine")
+
+	got := listRemotes("")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 remotes, got %+v", got)
+	}
+	if got[0].name != "gh" || got[0].repo.path != "org/repo" {
+		t.Errorf("first remote = %+v", got[0])
+	}
+	if got[1].name != "mine" || got[1].repo.kind != forgeGitLab || got[1].repo.path != "me/group/repo" {
+		t.Errorf("second remote = %+v", got[1])
+	}
+	if tracking := trackingRemote(); tracking != "mine" {
+		t.Errorf("trackingRemote() = %q, want %q", tracking, "mine")
+	}
+}
@@ -0,0 +1,409 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Commits listed in each author's timeline in the report
+	reportTimelineCommits = 20
+	// Directories listed in the path ownership table, and owners per directory
+	reportOwnershipDirs = 30
+	reportOwnersPerDir  = 3
+	sparklineWidth      = 120
+	sparklineHeight     = 24
+)
+
+// authorStats is one author's row in the report
+type authorStats struct {
+	name     string
+	email    string
+	commits  int
+	added    int
+	deleted  int
+	first    time.Time
+	last     time.Time
+	perMonth []int           // Commits in each month of the report period
+	recent   []historyCommit // Newest first, at most reportTimelineCommits
+}
+
+// dirOwnership is how the changed lines in a directory are split among authors
+type dirOwnership struct {
+	dir    string
+	lines  int
+	owners []ownerShare
+}
+
+// ownerShare is one author's part of a directory's changed lines
+type ownerShare struct {
+	name    string
+	lines   int
+	percent float64
+}
+
+// report is everything shown in the HTML report
+type report struct {
+	title     string
+	repoURL   string
+	rangeDesc string
+	generated time.Time
+	commits   int
+	start     time.Time // First month of the report period
+	months    int
+	authors   []authorStats
+	dirs      []dirOwnership
+}
+
+// Subcommand: report
+func runReportCommand(args []string) {
+	// Take out the report's own options; everything else is handled like
+	// in interactive mode (repo directory, revision range, paths, git options)
+	htmlPath := ""
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--html" && i+1 < len(args):
+			i++
+			htmlPath = args[i]
+		case strings.HasPrefix(arg, "--html="):
+			htmlPath = strings.TrimPrefix(arg, "--html=")
+		case arg == "--":
+			rest = append(rest, args[i:]...)
+			i = len(args)
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	if htmlPath == "" {
+		fmt.Fprintln(os.Stderr, "Usage: gh shortlog report --html <file> [options] [<revision-range>] [[--] <path>...]")
+		exit(2)
+	}
+
+	parseArgs(rest)
+	if baseURL == "" || orgAndRepo == "" {
+		setupGitHubInfo()
+	}
+	setupClone()
+	warnClone(true)
+
+	commits, err := readHistory("", true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		exit(1)
+	}
+	requireCommits(len(commits))
+
+	r := buildReport(commits)
+	r.title = reportTitle()
+	if orgAndRepo != "" {
+		r.repoURL = currentForge().webURL()
+	}
+	r.rangeDesc = "Full history"
+	if len(gitArgs) > 0 {
+		r.rangeDesc = strings.Join(gitArgs, " ")
+	}
+	r.generated = time.Now()
+
+	out := os.Stdout
+	if htmlPath != "-" {
+		f, err := os.Create(htmlPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating report: %v\n", err)
+			exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeHTMLReport(out, r); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		exit(1)
+	}
+}
+
+// reportTitle names the repo the report is for
+func reportTitle() string {
+	if orgAndRepo != "" {
+		return orgAndRepo
+	}
+	dir := workDir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	return filepath.Base(dir)
+}
+
+// buildReport computes the report from commits (newest first)
+func buildReport(commits []historyCommit) report {
+	r := report{commits: len(commits)}
+	if len(commits) == 0 {
+		return r
+	}
+
+	starts, perMonth := periodCounts(commits, "month")
+	r.start, r.months = starts[0], len(starts)
+
+	byAuthor := make(map[string]*authorStats)
+	var authors []*authorStats
+	dirLines := make(map[string]map[string]int) // dir → author name → lines
+	prefix := commonDir(commits)
+	for _, c := range commits {
+		a, ok := byAuthor[authorKey(c)]
+		if !ok {
+			a = &authorStats{name: c.name, email: c.email, first: c.when, last: c.when, perMonth: perMonth[authorKey(c)]}
+			byAuthor[authorKey(c)] = a
+			authors = append(authors, a)
+		}
+		a.commits++
+		if c.when.Before(a.first) {
+			a.first = c.when
+		}
+		if c.when.After(a.last) {
+			a.last = c.when
+		}
+		if len(a.recent) < reportTimelineCommits {
+			a.recent = append(a.recent, c)
+		}
+		for _, f := range c.files {
+			a.added += f.added
+			a.deleted += f.deleted
+			dir := ownershipDir(f.path, prefix)
+			if dirLines[dir] == nil {
+				dirLines[dir] = make(map[string]int)
+			}
+			dirLines[dir][c.name] += f.added + f.deleted
+		}
+	}
+
+	for _, a := range authors {
+		r.authors = append(r.authors, *a)
+	}
+	sort.SliceStable(r.authors, func(i, j int) bool {
+		if r.authors[i].commits != r.authors[j].commits {
+			return r.authors[i].commits > r.authors[j].commits
+		}
+		return r.authors[i].name < r.authors[j].name
+	})
+
+	for dir, byName := range dirLines {
+		d := dirOwnership{dir: dir}
+		for name, lines := range byName {
+			d.lines += lines
+			d.owners = append(d.owners, ownerShare{name: name, lines: lines})
+		}
+		if d.lines == 0 {
+			continue
+		}
+		sort.Slice(d.owners, func(i, j int) bool {
+			if d.owners[i].lines != d.owners[j].lines {
+				return d.owners[i].lines > d.owners[j].lines
+			}
+			return d.owners[i].name < d.owners[j].name
+		})
+		for i := range d.owners {
+			d.owners[i].percent = 100 * float64(d.owners[i].lines) / float64(d.lines)
+		}
+		d.owners = d.owners[:min(len(d.owners), reportOwnersPerDir)]
+		r.dirs = append(r.dirs, d)
+	}
+	sort.Slice(r.dirs, func(i, j int) bool {
+		if r.dirs[i].lines != r.dirs[j].lines {
+			return r.dirs[i].lines > r.dirs[j].lines
+		}
+		return r.dirs[i].dir < r.dirs[j].dir
+	})
+	r.dirs = r.dirs[:min(len(r.dirs), reportOwnershipDirs)]
+
+	return r
+}
+
+// commonDir returns the deepest directory that all changed files are in
+// ("" for the top level), so ownership is shown one level below it
+func commonDir(commits []historyCommit) string {
+	var common []string
+	first := true
+	for _, c := range commits {
+		for _, f := range c.files {
+			dir := strings.Split(filepath.ToSlash(filepath.Dir(f.path)), "/")
+			if dir[0] == "." {
+				return ""
+			}
+			if first {
+				common = dir
+				first = false
+				continue
+			}
+			n := 0
+			for n < len(common) && n < len(dir) && common[n] == dir[n] {
+				n++
+			}
+			common = common[:n]
+			if n == 0 {
+				return ""
+			}
+		}
+	}
+	return strings.Join(common, "/")
+}
+
+// ownershipDir returns the directory one level below prefix that path is in
+// (or prefix itself, for files directly in it)
+func ownershipDir(path, prefix string) string {
+	rest := path
+	if prefix != "" {
+		rest = strings.TrimPrefix(path, prefix+"/")
+	}
+	dir, _, found := strings.Cut(rest, "/")
+	if !found {
+		if prefix == "" {
+			return "(top level)"
+		}
+		return prefix + "/"
+	}
+	if prefix != "" {
+		dir = prefix + "/" + dir
+	}
+	return dir + "/"
+}
+
+// sparkline renders per-period counts as a small inline SVG line chart
+func sparkline(counts []int) template.HTML {
+	maxCount := 1
+	for _, n := range counts {
+		maxCount = max(maxCount, n)
+	}
+
+	var points strings.Builder
+	step := 0.0
+	if len(counts) > 1 {
+		step = float64(sparklineWidth) / float64(len(counts)-1)
+	}
+	for i, n := range counts {
+		x := float64(i) * step
+		y := float64(sparklineHeight-1) - float64(n)/float64(maxCount)*float64(sparklineHeight-2)
+		fmt.Fprintf(&points, "%.1f,%.1f ", x, y)
+	}
+
+	return template.HTML(fmt.Sprintf(`<svg class="spark" width="%d" height="%d" viewBox="0 0 %d %d"><polyline points="%s"/></svg>`,
+		sparklineWidth, sparklineHeight, sparklineWidth, sparklineHeight, strings.TrimSpace(points.String())))
+}
+
+// writeHTMLReport renders r as a standalone HTML page (no scripts, no
+// external stylesheets or images)
+func writeHTMLReport(w io.Writer, r report) error {
+	commitURL := func(sha string) string {
+		if baseURL == "" {
+			return ""
+		}
+		return baseURL + "/" + sha
+	}
+	funcs := template.FuncMap{
+		"sparkline": sparkline,
+		"commitURL": commitURL,
+		"short":     func(sha string) string { return sha[:min(len(sha), 10)] },
+		"date":      func(t time.Time) string { return t.Format("2006-01-02") },
+		"percent":   func(p float64) string { return fmt.Sprintf("%.0f%%", p) },
+		"inc":       func(i int) int { return i + 1 },
+		"bare":      func(email string) string { return strings.Trim(email, "<>") },
+		"month":     func(i int) string { return r.start.AddDate(0, i, 0).Format("Jan 2006") },
+	}
+	tmpl, err := template.New("report").Funcs(funcs).Parse(reportTemplate)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, reportView(r))
+}
+
+// reportView exposes report fields to the template (which can only see
+// exported names)
+func reportView(r report) map[string]any {
+	var authors []map[string]any
+	for _, a := range r.authors {
+		var recent []map[string]any
+		for _, c := range a.recent {
+			recent = append(recent, map[string]any{"SHA": c.sha, "When": c.when, "Subject": c.subject})
+		}
+		authors = append(authors, map[string]any{
+			"Name": a.name, "Email": a.email, "Commits": a.commits,
+			"Added": a.added, "Deleted": a.deleted, "First": a.first, "Last": a.last,
+			"PerMonth": a.perMonth, "Recent": recent, "More": a.commits - len(a.recent),
+		})
+	}
+	var dirs []map[string]any
+	for _, d := range r.dirs {
+		var owners []map[string]any
+		for _, o := range d.owners {
+			owners = append(owners, map[string]any{"Name": o.name, "Lines": o.lines, "Percent": o.percent})
+		}
+		dirs = append(dirs, map[string]any{"Dir": d.dir, "Lines": d.lines, "Owners": owners})
+	}
+	return map[string]any{
+		"Title": r.title, "RepoURL": r.repoURL, "Range": r.rangeDesc,
+		"Generated": r.generated.Format("2006-01-02 15:04 MST"),
+		"Commits":   r.commits, "LastMonth": r.months - 1,
+		"Authors": authors, "Dirs": dirs,
+	}
+}
+
+const reportTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Contributors: {{.Title}}</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em auto; max-width: 72em; padding: 0 1em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 2em; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #ddd; vertical-align: top; }
+th { background: #f4f4f4; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+.added { color: #1a7f37; }
+.deleted { color: #cf222e; }
+.email { color: #666; font-size: 90%; }
+.spark polyline { fill: none; stroke: #0969da; stroke-width: 1.5; }
+details summary { cursor: pointer; color: #0969da; }
+details ul { margin: 0.4em 0; padding-left: 1.2em; }
+code { font-size: 90%; }
+.bar { display: inline-block; height: 0.7em; background: #0969da; opacity: 0.6; margin-right: 0.4em; }
+</style>
+</head>
+<body>
+<h1>Contributors: {{if .RepoURL}}<a href="{{.RepoURL}}">{{.Title}}</a>{{else}}{{.Title}}{{end}}</h1>
+<div class="meta">{{.Range}} · {{.Commits}} commits · {{len .Authors}} authors · generated {{.Generated}}</div>
+
+<h2>Authors</h2>
+<table>
+<tr><th>#</th><th>Author</th><th>Commits</th><th>Lines</th><th>Active</th><th>Commits per month{{if .Authors}} ({{month 0}} – {{month .LastMonth}}){{end}}</th></tr>
+{{range $i, $a := .Authors}}<tr>
+<td class="num">{{inc $i}}</td>
+<td>{{$a.Name}}<br><a class="email" href="mailto:{{bare $a.Email}}">{{bare $a.Email}}</a>
+<details><summary>Timeline</summary><ul>
+{{range $a.Recent}}<li><code>{{if commitURL .SHA}}<a href="{{commitURL .SHA}}">{{short .SHA}}</a>{{else}}{{short .SHA}}{{end}}</code> {{date .When}} {{.Subject}}</li>
+{{end}}{{if $a.More}}<li>… and {{$a.More}} earlier commits</li>{{end}}
+</ul></details></td>
+<td class="num">{{$a.Commits}}</td>
+<td class="num"><span class="added">+{{$a.Added}}</span> <span class="deleted">−{{$a.Deleted}}</span></td>
+<td>{{date $a.First}} – {{date $a.Last}}</td>
+<td>{{sparkline $a.PerMonth}}</td>
+</tr>
+{{end}}</table>
+
+{{if .Dirs}}<h2>Path ownership</h2>
+<table>
+<tr><th>Directory</th><th>Lines changed</th><th>Top authors</th></tr>
+{{range .Dirs}}<tr>
+<td><code>{{.Dir}}</code></td>
+<td class="num">{{.Lines}}</td>
+<td>{{range .Owners}}<div><span class="bar" style="width: {{.Percent | printf "%.1f"}}px"></span>{{.Name}} ({{percent .Percent}})</div>{{end}}</td>
+</tr>
+{{end}}</table>{{end}}
+</body>
+</html>
+`
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func testCommit(name string, when string, files ...fileChange) historyCommit {
+	t, _ := time.Parse("2006-01-02", when)
+	return historyCommit{
+		sha:     strings.Repeat("a", 40),
+		name:    name,
+		email:   "<" + strings.ToLower(strings.Fields(name)[0]) + "@example.com>",
+		when:    t,
+		subject: "Change by " + name,
+		files:   files,
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	commits := []historyCommit{
+		testCommit("Jane Smith", "2024-03-10", fileChange{"src/parser/lex.go", 30, 10}),
+		testCommit("John Doe", "2024-03-01", fileChange{"src/ui/view.go", 5, 5}),
+		testCommit("Jane Smith", "2024-01-15", fileChange{"src/parser/parse.go", 50, 0}, fileChange{"src/ui/view.go", 5, 0}),
+	}
+
+	r := buildReport(commits)
+	if r.commits != 3 || r.months != 3 {
+		t.Errorf("commits = %d, months = %d; want 3, 3", r.commits, r.months)
+	}
+	if len(r.authors) != 2 || r.authors[0].name != "Jane Smith" {
+		t.Fatalf("unexpected authors: %+v", r.authors)
+	}
+
+	jane := r.authors[0]
+	if jane.commits != 2 || jane.added != 85 || jane.deleted != 10 {
+		t.Errorf("jane: commits %d, +%d −%d; want 2, +85 −10", jane.commits, jane.added, jane.deleted)
+	}
+	if got := jane.perMonth; len(got) != 3 || got[0] != 1 || got[1] != 0 || got[2] != 1 {
+		t.Errorf("jane per month = %v, want [1 0 1]", got)
+	}
+
+	// All files are under src/, so ownership is shown for its subdirectories
+	if len(r.dirs) != 2 || r.dirs[0].dir != "src/parser/" || r.dirs[1].dir != "src/ui/" {
+		t.Fatalf("unexpected dirs: %+v", r.dirs)
+	}
+	ui := r.dirs[1]
+	if ui.lines != 15 || ui.owners[0].name != "John Doe" || int(ui.owners[0].percent) != 66 {
+		t.Errorf("unexpected ownership of src/ui/: %+v", ui)
+	}
+}
+
+func TestOwnershipDir(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   string
+	}{
+		{"README.md", "", "(top level)"},
+		{"src/main.go", "", "src/"},
+		{"src/ui/view.go", "src", "src/ui/"},
+		{"src/main.go", "src", "src/"},
+	}
+	for _, tt := range tests {
+		if got := ownershipDir(tt.path, tt.prefix); got != tt.want {
+			t.Errorf("ownershipDir(%q, %q) = %q, want %q", tt.path, tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	oldBaseURL := baseURL
+	defer func() { baseURL = oldBaseURL }()
+	baseURL = "https://github.com/org/repo/commit"
+
+	commits := []historyCommit{
+		testCommit("Jane <script>", "2024-03-10", fileChange{"main.go", 1, 0}),
+	}
+	r := buildReport(commits)
+	r.title = "org/repo"
+	r.repoURL = "https://github.com/org/repo"
+	r.rangeDesc = "Full history"
+
+	var out strings.Builder
+	if err := writeHTMLReport(&out, r); err != nil {
+		t.Fatalf("writeHTMLReport: %v", err)
+	}
+	html := out.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		`<a href="https://github.com/org/repo">org/repo</a>`,
+		`href="https://github.com/org/repo/commit/` + commits[0].sha + `"`,
+		"Jane &lt;script&gt;",
+		`<svg class="spark"`,
+		"<code>(top level)</code>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("report missing %q", want)
+		}
+	}
+	for _, unwanted := range []string{"<script", "src=\"http", "<link"} {
+		if strings.Contains(html, unwanted) {
+			t.Errorf("report should be self-contained, but contains %q", unwanted)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Repositories given by URL (or as gh:owner/repo) are cloned into the user
+// cache dir, bare and without file contents (blobless), and fetched again
+// on later runs
+
+// Repository URLs: with a scheme (https://, ssh://, file://, …), or scp-like
+// (git@github.com:owner/repo.git)
+var repoURLRe = regexp.MustCompile(`^(?:[a-z][a-z0-9+.-]*://|[^@/:\s]+@[^/:\s]+:)\S+$`)
+
+// Shorthand for a GitHub repository: gh:owner/repo. A bare owner/repo is
+// left for git, since it's as likely a path or a remote's branch (or a typo
+// of one) as a repository to clone
+var ownerRepoRe = regexp.MustCompile(`^gh:([A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+)$`)
+
+// repoURL returns the URL of the repository that arg names, if it's a URL,
+// or gh:owner/repo for GitHub (on GH_HOST, if set)
+func repoURL(arg string) (string, bool) {
+	if repoURLRe.MatchString(arg) {
+		return arg, true
+	}
+	m := ownerRepoRe.FindStringSubmatch(arg)
+	if m == nil || strings.Contains(m[1], "..") {
+		return "", false
+	}
+	host := os.Getenv("GH_HOST")
+	if host == "" {
+		host = "github.com"
+	}
+	return "https://" + host + "/" + strings.TrimSuffix(m[1], ".git") + ".git", true
+}
+
+// repoCachePath returns where, under cacheDir, the clone of url goes:
+// repos/<host>/<path>.git (or repos/file/<path>.git for a local one)
+func repoCachePath(cacheDir, url string) string {
+	var path string
+	if scheme, rest, ok := strings.Cut(url, "://"); ok {
+		path = rest
+		if scheme == "file" {
+			path = "file/" + rest
+		}
+	} else {
+		path = strings.Replace(url, ":", "/", 1) // scp-like: host:path
+	}
+	// Leave out the user (git@…)
+	if first, rest, ok := strings.Cut(path, "/"); ok {
+		if _, host, ok := strings.Cut(first, "@"); ok {
+			path = host + "/" + rest
+		}
+	}
+
+	var names []string
+	for _, name := range strings.Split(strings.TrimSuffix(path, ".git"), "/") {
+		name = strings.ReplaceAll(name, ":", "_") // Port
+		if name != "" && name != "." && name != ".." {
+			names = append(names, name)
+		}
+	}
+	return filepath.Join(cacheDir, "repos", filepath.Join(names...)+".git")
+}
+
+// cachedClone clones the repository at url under cacheDir, or if it was
+// cloned before, fetches what's new; it returns the clone's path. If the
+// fetch fails (e.g. offline), the clone is used as it is. Progress and git's
+// messages go to w.
+func cachedClone(w io.Writer, cacheDir, url string) (string, error) {
+	path := repoCachePath(cacheDir, url)
+	git := func(args ...string) error {
+		cmd := exec.Command("git", args...)
+		cmd.Stdout, cmd.Stderr = w, w
+		return cmd.Run()
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		fmt.Fprintf(w, "Fetching %s…\n", url)
+		if err := git("-C", path, "fetch", "--quiet", "--prune", "--tags", "origin"); err != nil {
+			fmt.Fprintf(w, "Warning: couldn't fetch %s, so using the commits fetched before\n", url)
+		}
+		return path, nil
+	}
+
+	// Clone into a temp dir next to the final one, so that a clone that's
+	// interrupted isn't taken for a complete one next time
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(path), ".clone-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	fmt.Fprintf(w, "Cloning %s…\n", url)
+	if err := git("clone", "--quiet", "--bare", "--filter=blob:none", url, tmp); err != nil {
+		return "", fmt.Errorf("cloning %s failed", url)
+	}
+	// A bare clone has no refspec, so later fetches would update nothing
+	if err := git("-C", tmp, "config", "remote.origin.fetch", "+refs/heads/*:refs/heads/*"); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		// Another run cloned it at the same time
+		if _, statErr := os.Stat(path); statErr == nil {
+			return path, nil
+		}
+		return "", err
+	}
+	return path, nil
+}
+
+// useRepoURL makes the (cached) clone of the repository at url the one to
+// use
+func useRepoURL(url string) {
+	cacheDir, err := os.UserCacheDir()
+	if err == nil {
+		cacheDir = filepath.Join(cacheDir, "gh-shortlog")
+		workDir, err = cachedClone(os.Stderr, cacheDir, url)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoURL(t *testing.T) {
+	t.Setenv("GH_HOST", "")
+
+	tests := []struct {
+		arg  string
+		want string // Empty if it isn't a repository
+	}{
+		{"https://github.com/cli/cli", "https://github.com/cli/cli"},
+		{"git@gitlab.com:group/project.git", "git@gitlab.com:group/project.git"},
+		{"file:///srv/mirrors/app.git", "file:///srv/mirrors/app.git"},
+		{"gh:cli/cli", "https://github.com/cli/cli.git"},
+		{"gh:cli/cli.git", "https://github.com/cli/cli.git"},
+		{"cli/cli", ""}, // Only taken as a repository with gh:
+		{"origin/main", ""},
+		{"gh:cli", ""},
+		{"gh:../cli", ""},
+		{"HEAD~10..HEAD", ""},
+		{"v1.0..v2.0", ""},
+		{"main", ""},
+	}
+	for _, tt := range tests {
+		got, ok := repoURL(tt.arg)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("repoURL(%q) = %q, %v; want %q", tt.arg, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRepoCachePath(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/cli/cli.git", "repos/github.com/cli/cli.git"},
+		{"https://github.com/cli/cli", "repos/github.com/cli/cli.git"},
+		{"git@gitlab.com:group/sub/project.git", "repos/gitlab.com/group/sub/project.git"},
+		{"ssh://git@git.example.com:2222/team/app.git", "repos/git.example.com_2222/team/app.git"},
+		{"file:///srv/mirrors/app.git", "repos/file/srv/mirrors/app.git"},
+		{"https://example.com/../../etc", "repos/example.com/etc.git"},
+	}
+	for _, tt := range tests {
+		if got := repoCachePath("/cache", tt.url); got != filepath.Join("/cache", tt.want) {
+			t.Errorf("repoCachePath(%q) = %q, want %q", tt.url, got, filepath.Join("/cache", tt.want))
+		}
+	}
+}
+
+func TestCachedClone(t *testing.T) {
+	origin, git := testRepo(t)
+	git("commit", "-q", "--allow-empty", "-m", "One")
+	base := t.TempDir()
+
+	cacheDir := filepath.Join(base, "cache")
+	count := func(path string) string {
+		out, _ := exec.Command("git", "-C", path, "rev-list", "--count", "HEAD").Output()
+		return strings.TrimSpace(string(out))
+	}
+	path, err := cachedClone(io.Discard, cacheDir, "file://"+origin)
+	if err != nil {
+		t.Fatalf("cachedClone: %v", err)
+	}
+	if path != repoCachePath(cacheDir, "file://"+origin) || findGitRoot(path) != path || count(path) != "1" {
+		t.Errorf("clone at %q has %s commits, want 1", path, count(path))
+	}
+
+	// Later runs fetch what's new
+	git("commit", "-q", "--allow-empty", "-m", "Two")
+	if path, err = cachedClone(io.Discard, cacheDir, "file://"+origin); err != nil || count(path) != "2" {
+		t.Errorf("after fetching: %s commits (%v), want 2", count(path), err)
+	}
+	if _, err := cachedClone(io.Discard, cacheDir, "file://"+filepath.Join(base, "missing")); err == nil {
+		t.Error("expected an error cloning a missing repository")
+	}
+
+	// Two first runs at the same time both use the clone
+	otherCache := filepath.Join(base, "other")
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := cachedClone(io.Discard, otherCache, "file://"+origin)
+			errs <- err
+		}()
+	}
+	for range 2 {
+		if err := <-errs; err != nil {
+			t.Errorf("concurrent cachedClone: %v", err)
+		}
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// Without SIGWINCH, the size is only read when the screen is opened
+func notifyResize(c chan<- os.Signal) {}
+
+func stopResize(c chan<- os.Signal) {}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Authors with no commits in this long are counted as inactive
+const riskInactiveAfter = 180 * 24 * time.Hour
+
+// dirRisk is how concentrated the knowledge of one directory is
+type dirRisk struct {
+	dir           string // With a trailing slash
+	lines         int    // Lines changed (added + deleted)
+	owners        []ownerShare
+	cover50       int      // Fewest authors that made half the changes
+	cover80       int      // Fewest authors that made 80% of the changes
+	inactive      []string // Authors who made changes here but have gone inactive
+	inactiveShare float64  // Percentage of the changes made by inactive authors
+}
+
+// Subcommand: risk
+func runRiskCommand(args []string) {
+	parseArgs(args)
+	setup()
+	warnClone(true)
+
+	commits, err := readHistory("", true)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading history: %v\n", err)
+		exit(1)
+	}
+	risks := buildRisk(commits, riskRoots(gitArgs), time.Now())
+	if len(risks) == 0 {
+		fmt.Fprintln(os.Stderr, "No changes in any directory")
+		return
+	}
+
+	// Drill down into a directory's authors, then come back to the list
+	baseArgs := gitArgs
+	for {
+		dir, ok := pickRiskDir(risks)
+		if !ok {
+			return
+		}
+		gitArgs = dirArgs(baseArgs, dir)
+		runInteractive()
+		gitArgs = baseArgs
+	}
+}
+
+// riskRoots returns the directories (relative to the top of the repo, with
+// a trailing slash) that the path filters in args are, or the files they
+// name are in; none if there are no path filters, or one git has to match
+// (a glob, or other magic than :(top))
+func riskRoots(args []string) []string {
+	var paths []string
+	for i, arg := range args {
+		if arg == "--" {
+			paths = args[i+1:]
+			break
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	// Paths are relative to the current directory, and the files in them
+	// are looked up in the work tree (if there is one)
+	prefix, top := "", ""
+	if out, err := gitOutput("rev-parse", "--show-prefix", "--show-toplevel"); err == nil {
+		lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+		if len(lines) == 2 {
+			prefix, top = lines[0], lines[1]
+		}
+	}
+
+	var roots []string
+	for _, p := range paths {
+		if rest, ok := strings.CutPrefix(p, ":(top)"); ok {
+			p = rest
+		} else if rest, ok := strings.CutPrefix(p, ":/"); ok {
+			p = rest
+		} else if strings.HasPrefix(p, ":") {
+			return nil
+		} else {
+			p = path.Join(prefix, filepath.ToSlash(p))
+		}
+		if strings.ContainsAny(p, "*?[") {
+			return nil
+		}
+		p = path.Clean(p)
+		if top != "" {
+			if info, err := os.Stat(filepath.Join(top, p)); err == nil && !info.IsDir() {
+				p = path.Dir(p)
+			}
+		}
+		if p == "." || p == "" {
+			return nil
+		}
+		roots = append(roots, p+"/")
+	}
+	return roots
+}
+
+// underRoots reports whether dir (with a trailing slash) is, or is under,
+// one of roots; with no roots, every directory is
+func underRoots(dir string, roots []string) bool {
+	if len(roots) == 0 {
+		return true
+	}
+	for _, root := range roots {
+		if strings.HasPrefix(dir, root) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRisk computes the knowledge concentration of every directory that
+// commits changed files in (the roots and the directories under them, if
+// there are roots), riskiest first
+func buildRisk(commits []historyCommit, roots []string, now time.Time) []dirRisk {
+	lastCommit := make(map[string]time.Time) // Author name → newest commit
+	dirLines := make(map[string]map[string]int)
+	for _, c := range commits {
+		if c.when.After(lastCommit[c.name]) {
+			lastCommit[c.name] = c.when
+		}
+		for _, f := range c.files {
+			// Count the change in every directory above the file, up to
+			// the roots
+			dir := filepath.ToSlash(filepath.Dir(f.path))
+			for dir != "." && dir != "/" && dir != "" {
+				key := dir + "/"
+				if !underRoots(key, roots) {
+					break
+				}
+				if dirLines[key] == nil {
+					dirLines[key] = make(map[string]int)
+				}
+				dirLines[key][c.name] += f.added + f.deleted
+				dir = filepath.ToSlash(filepath.Dir(dir))
+			}
+		}
+	}
+
+	var risks []dirRisk
+	for dir, byName := range dirLines {
+		d := dirRisk{dir: dir}
+		inactiveLines := 0
+		for name, lines := range byName {
+			d.lines += lines
+			d.owners = append(d.owners, ownerShare{name: name, lines: lines})
+			if now.Sub(lastCommit[name]) > riskInactiveAfter {
+				d.inactive = append(d.inactive, name)
+				inactiveLines += lines
+			}
+		}
+		if d.lines == 0 {
+			continue
+		}
+		sort.Slice(d.owners, func(i, j int) bool {
+			if d.owners[i].lines != d.owners[j].lines {
+				return d.owners[i].lines > d.owners[j].lines
+			}
+			return d.owners[i].name < d.owners[j].name
+		})
+		sort.Strings(d.inactive)
+
+		covered := 0
+		for i := range d.owners {
+			d.owners[i].percent = 100 * float64(d.owners[i].lines) / float64(d.lines)
+			covered += d.owners[i].lines
+			if d.cover50 == 0 && 2*covered >= d.lines {
+				d.cover50 = i + 1
+			}
+			if d.cover80 == 0 && 10*covered >= 8*d.lines {
+				d.cover80 = i + 1
+			}
+		}
+		d.inactiveShare = 100 * float64(inactiveLines) / float64(d.lines)
+		risks = append(risks, d)
+	}
+
+	// Most knowledge per person first, so that big areas few people know
+	// come before tiny ones a single author touched; among those, the most
+	// already-lost knowledge, then the most concentrated, then the biggest
+	sort.Slice(risks, func(i, j int) bool {
+		a, b := risks[i], risks[j]
+		if a.atRisk() != b.atRisk() {
+			return a.atRisk() > b.atRisk()
+		}
+		if a.inactiveShare != b.inactiveShare {
+			return a.inactiveShare > b.inactiveShare
+		}
+		if a.owners[0].percent != b.owners[0].percent {
+			return a.owners[0].percent > b.owners[0].percent
+		}
+		if a.lines != b.lines {
+			return a.lines > b.lines
+		}
+		return a.dir < b.dir
+	})
+	return risks
+}
+
+// atRisk is how much knowledge of d hangs on each person: the lines changed
+// per author it takes to cover 80% of them, with the changes by inactive
+// authors (already lost) counting double
+func (d dirRisk) atRisk() float64 {
+	return float64(d.lines) * (1 + d.inactiveShare/100) / float64(d.cover80)
+}
+
+// formatRisk renders one aligned line per directory, for fzf
+func formatRisk(risks []dirRisk) string {
+	maxDir := 0
+	for _, d := range risks {
+		maxDir = max(maxDir, len(d.dir))
+	}
+
+	var b strings.Builder
+	for _, d := range risks {
+		top := d.owners[0]
+		inactive := ""
+		if len(d.inactive) > 0 {
+			inactive = fmt.Sprintf("  %sinactive: %s (%.0f%%)%s",
+				colorYellow, strings.Join(d.inactive, ", "), d.inactiveShare, colorReset)
+		}
+		fmt.Fprintf(&b, "%s%-*s%s  %3.0f%% %s%s%s  50%%: %d  80%%: %d  authors: %d  %slines: %d%s%s\n",
+			colorWhite, maxDir, d.dir, colorReset,
+			top.percent, colorGreen, top.name, colorReset,
+			d.cover50, d.cover80, len(d.owners),
+			colorCyan, d.lines, colorReset, inactive)
+	}
+	return b.String()
+}
+
+// pickRiskDir shows the directories and returns the one chosen
+func pickRiskDir(risks []dirRisk) (string, bool) {
+	c := choice{
+		header: "Riskiest areas first (top author's share, authors covering 50%/80% of changed lines)" +
+			"\nEnter: show the directory's authors  │  Esc: exit",
+		prompt: "Directory",
+		items:  strings.Split(strings.TrimRight(formatRisk(risks), "\n"), "\n"),
+	}
+	i, ok := ui.choose(c)
+	if !ok {
+		return "", false
+	}
+	return risks[i].dir, true
+}
+
+// dirArgs returns args with their paths replaced by dir (relative to the
+// top of the repo, like the paths git log --numstat reports)
+func dirArgs(args []string, dir string) []string {
+	var revs []string
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		revs = append(revs, arg)
+	}
+	return append(revs, "--", ":(top)"+dir)
+}
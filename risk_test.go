@@ -0,0 +1,132 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildRisk(t *testing.T) {
+	now := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	recent := now.AddDate(0, -1, 0)
+	old := now.AddDate(-1, 0, 0)
+	commits := []historyCommit{
+		{name: "Jane", when: recent, files: []fileChange{{"src/api/server.go", 60, 0}, {"docs/guide.md", 10, 0}}},
+		{name: "John", when: recent, files: []fileChange{{"src/api/client.go", 30, 10}}},
+		{name: "Ann", when: old, files: []fileChange{{"src/db/store.go", 90, 10}, {"README.md", 5, 0}}},
+		{name: "Jane", when: old, files: []fileChange{{"src/db/store.go", 0, 0}}},
+	}
+
+	dirsOf := func(risks []dirRisk) []string {
+		var dirs []string
+		for _, d := range risks {
+			dirs = append(dirs, d.dir)
+		}
+		return dirs
+	}
+	risks := buildRisk(commits, nil, now)
+	// Most lines per author covering 80% first (with inactive authors'
+	// counting double), so the tiny docs/ comes last, though one author
+	// made all its changes
+	if want := []string{"src/db/", "src/", "src/api/", "docs/"}; !reflect.DeepEqual(dirsOf(risks), want) {
+		t.Fatalf("dirs = %v, want %v", dirsOf(risks), want)
+	}
+
+	db := risks[0]
+	if db.cover50 != 1 || db.cover80 != 1 || db.owners[0].name != "Ann" || db.owners[0].percent != 100 {
+		t.Errorf("unexpected src/db/ risk: %+v", db)
+	}
+	if !reflect.DeepEqual(db.inactive, []string{"Ann"}) || db.inactiveShare != 100 {
+		t.Errorf("src/db/ inactive = %v (%.0f%%), want [Ann] (100%%)", db.inactive, db.inactiveShare)
+	}
+
+	api := risks[2]
+	if api.lines != 100 || api.owners[0].name != "Jane" || api.cover50 != 1 || api.cover80 != 2 || len(api.inactive) != 0 {
+		t.Errorf("unexpected src/api/ risk: %+v", api)
+	}
+
+	// Files at the top level aren't in any directory
+	for _, d := range risks {
+		if d.dir == "./" || d.dir == "/" {
+			t.Errorf("unexpected top-level entry %q", d.dir)
+		}
+	}
+
+	// With path filters, only the directories under them count
+	if got := dirsOf(buildRisk(commits, []string{"src/api/", "docs/"}, now)); !reflect.DeepEqual(got, []string{"src/api/", "docs/"}) {
+		t.Errorf("under src/api/ and docs/: dirs = %v", got)
+	}
+}
+
+func TestRiskRoots(t *testing.T) {
+	dir, git := testRepo(t)
+	if err := os.MkdirAll(filepath.Join(dir, "src", "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "src", "api", "server.go"), []byte("package api\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	git("add", ".")
+	git("commit", "-q", "-m", "Initial commit")
+	oldWorkDir := workDir
+	defer func() { workDir = oldWorkDir }()
+	workDir = dir
+
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"--since=2024-01-01"}, nil},
+		{[]string{"--", "src"}, []string{"src/"}},
+		{[]string{"main", "--", "src/api/", "docs"}, []string{"src/api/", "docs/"}},
+		{[]string{"--", "src/api/server.go"}, []string{"src/api/"}}, // A file's directory
+		{[]string{"--", ":(top)src"}, []string{"src/"}},
+		{[]string{"--", "."}, nil},
+		{[]string{"--", "*.go"}, nil}, // git matches globs
+	}
+	for _, tt := range tests {
+		if got := riskRoots(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("riskRoots(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestFormatRisk(t *testing.T) {
+	risks := []dirRisk{{
+		dir:           "src/db/",
+		lines:         100,
+		owners:        []ownerShare{{"Ann", 100, 100}},
+		cover50:       1,
+		cover80:       1,
+		inactive:      []string{"Ann"},
+		inactiveShare: 100,
+	}}
+	got := stripANSI(formatRisk(risks))
+	want := "src/db/  100% Ann  50%: 1  80%: 1  authors: 1  lines: 100  inactive: Ann (100%)\n"
+	if got != want {
+		t.Errorf("formatRisk = %q, want %q", got, want)
+	}
+	if dir, _, _ := strings.Cut(got, "  "); dir != "src/db/" {
+		t.Errorf("directory field = %q", dir)
+	}
+}
+
+func TestDirArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"--", ":(top)src/db/"}},
+		{[]string{"--since=2024-01-01", "v1..v2"}, []string{"--since=2024-01-01", "v1..v2", "--", ":(top)src/db/"}},
+		{[]string{"main", "--", "src/"}, []string{"main", "--", ":(top)src/db/"}},
+	}
+	for _, tt := range tests {
+		if got := dirArgs(tt.args, "src/db/"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("dirArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package shortlog
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Commit is a commit as read by Query.Commits
+type Commit struct {
+	SHA     string
+	Name    string // Author (or committer) name, after .mailmap mapping
+	Email   string // Author (or committer) email, with <>, after .mailmap mapping
+	When    time.Time
+	Subject string
+	Files   []FileChange // Only if Commits was asked for them
+}
+
+// FileChange is one file's line counts in a commit (0/0 for binary files)
+type FileChange struct {
+	Path    string
+	Added   int
+	Deleted int
+}
+
+// LogFormat is the git log --format that ParseLog parses: each record
+// starts with RS, fields separated by US
+const LogFormat = "--format=%x1e%H%x1f%aN%x1f%aE%x1f%aI%x1f%s"
+
+// Same, with the committer instead of the author
+const committerLogFormat = "--format=%x1e%H%x1f%cN%x1f%cE%x1f%cI%x1f%s"
+
+// Commits returns q's commits (newest first), with per-file line counts if
+// withFiles is set
+func (q Query) Commits(withFiles bool) ([]Commit, error) {
+	args := []string{"log", LogFormat}
+	if q.Group == ByCommitter {
+		args[1] = committerLogFormat
+	}
+	if withFiles && q.PathsOnly {
+		// Rename detection compares contents too
+		args = append(args, "--name-only", "--no-renames")
+	} else if withFiles {
+		args = append(args, "--numstat")
+	}
+	out, err := q.git(append(args, q.logArgs()...)...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLog(string(out)), nil
+}
+
+// ParseLog parses git log output in LogFormat (with --numstat or
+// --name-only lines)
+func ParseLog(out string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e") {
+		lines := strings.Split(strings.TrimSpace(record), "\n")
+		fields := strings.SplitN(lines[0], "\x1f", 5)
+		if len(fields) < 5 {
+			continue
+		}
+		when, _ := time.Parse(time.RFC3339, fields[3])
+		c := Commit{
+			SHA:     fields[0],
+			Name:    fields[1],
+			Email:   "<" + fields[2] + ">",
+			When:    when,
+			Subject: fields[4],
+		}
+
+		// --numstat lines: "added<TAB>deleted<TAB>path" ("-" for binary
+		// files); --name-only ones are just the path
+		for _, line := range lines[1:] {
+			parts := strings.SplitN(line, "\t", 3)
+			if len(parts) == 1 && line != "" {
+				c.Files = append(c.Files, FileChange{Path: line})
+				continue
+			}
+			if len(parts) != 3 {
+				continue
+			}
+			added, _ := strconv.Atoi(parts[0])
+			deleted, _ := strconv.Atoi(parts[1])
+			c.Files = append(c.Files, FileChange{renamedPath(parts[2]), added, deleted})
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// renamedPath returns the new path from a --numstat rename
+// ("old => new" or "dir/{old => new}/file")
+func renamedPath(path string) string {
+	if open := strings.Index(path, "{"); open >= 0 {
+		if end := strings.Index(path[open:], "}"); end >= 0 {
+			inner := path[open+1 : open+end]
+			if _, after, found := strings.Cut(inner, " => "); found {
+				path = path[:open] + after + path[open+end+1:]
+				return strings.ReplaceAll(path, "//", "/")
+			}
+		}
+	}
+	if _, after, found := strings.Cut(path, " => "); found {
+		return after
+	}
+	return path
+}
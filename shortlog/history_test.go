@@ -0,0 +1,78 @@
+package shortlog
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestRenamedPath(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"src/main.go", "src/main.go"},
+		{"old.go => new.go", "new.go"},
+		{"src/{old => new}/main.go", "src/new/main.go"},
+		{"src/{ => pkg}/main.go", "src/pkg/main.go"},
+		{"src/{pkg => }/main.go", "src/main.go"},
+	}
+	for _, tt := range tests {
+		if got := renamedPath(tt.input); got != tt.want {
+			t.Errorf("renamedPath(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseLog(t *testing.T) {
+	out := "\x1eaaa\x1fJane Smith\x1fjane@example.com\x1f2024-03-05T10:00:00+01:00\x1fAdd parser\n\n" +
+		"10\t2\tsrc/parser.go\n" +
+		"\x1ebbb\x1fJohn Doe\x1fjohn@example.com\x1f2024-02-01T09:30:00Z\x1fInitial commit\n"
+
+	commits := ParseLog(out)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+	c := commits[0]
+	if c.SHA != "aaa" || c.Name != "Jane Smith" || c.Email != "<jane@example.com>" || c.Subject != "Add parser" {
+		t.Errorf("unexpected commit: %+v", c)
+	}
+	if len(c.Files) != 1 || c.Files[0] != (FileChange{"src/parser.go", 10, 2}) {
+		t.Errorf("files = %+v", c.Files)
+	}
+}
+
+func TestQueryCommits(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := testRepo(t)
+
+	commits, err := Query{Dir: dir, Paths: []string{"docs"}}.Commits(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 1 || commits[0].Name != "John Doe" || commits[0].Subject != "Write docs" {
+		t.Fatalf("unexpected commits: %+v", commits)
+	}
+	if f := commits[0].Files; len(f) != 1 || f[0] != (FileChange{"docs/guide.md", 1, 0}) {
+		t.Errorf("files = %+v", f)
+	}
+
+	commits, err = Query{Dir: dir, Paths: []string{"docs"}, PathsOnly: true}.Commits(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f := commits[0].Files; len(f) != 1 || f[0] != (FileChange{Path: "docs/guide.md"}) {
+		t.Errorf("files without line counts = %+v", f)
+	}
+
+	commits, err = Query{Dir: dir, Group: ByCommitter}.Commits(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range commits {
+		if c.Name != "Release Bot" {
+			t.Errorf("expected the committer, got %q", c.Name)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+// Package shortlog computes contributor statistics from git history: the
+// per-author commit counts that git shortlog gives, and the commits behind
+// them. It runs git, so git must be on the PATH.
+package shortlog
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Group is what commits are counted by
+type Group int
+
+const (
+	ByAuthor    Group = iota // The commit's author (git shortlog's default)
+	ByCommitter              // The commit's committer (git shortlog -c)
+)
+
+// Query selects the commits to compute statistics for
+type Query struct {
+	Dir   string   // Any directory in the repository; empty for the current one
+	Range []string // Revisions and ranges, e.g. "v1.0..v2.0"; HEAD if there are none
+	Paths []string // Only commits that change these paths
+	Since string   // Only commits after this date (anything git understands, e.g. "3 months ago")
+	Until string   // Only commits before this date
+	Group Group
+	Args  []string // Other git log/shortlog arguments, passed through (may include revisions and "-- paths")
+
+	// PathsOnly leaves out the line counts of Commits' files (they're 0),
+	// which need the files' contents: a blobless partial clone would fetch
+	// them one at a time
+	PathsOnly bool
+}
+
+// Entry is one author's (or committer's) line of the shortlog
+type Entry struct {
+	Name  string
+	Email string // With angle brackets, as git shortlog -e prints it
+	Count int
+}
+
+// Result is the shortlog for a Query
+type Result struct {
+	Entries []Entry // Most commits first, then by name
+	Commits int     // Total number of commits
+}
+
+// Run computes the shortlog for q
+func (q Query) Run() (Result, error) {
+	args := []string{"shortlog", "-n", "-s", "-e"}
+	if q.Group == ByCommitter {
+		args = append(args, "-c")
+	}
+	out, err := q.git(append(args, q.logArgs()...)...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r := Result{Entries: ParseShortlog(string(out))}
+	for _, e := range r.Entries {
+		r.Commits += e.Count
+	}
+	return r, nil
+}
+
+// logArgs returns the arguments that select q's commits: date limits,
+// Args, and the revisions (HEAD if none) and paths
+func (q Query) logArgs() []string {
+	var args []string
+	if q.Since != "" {
+		args = append(args, "--since="+q.Since)
+	}
+	if q.Until != "" {
+		args = append(args, "--until="+q.Until)
+	}
+
+	// Range goes before any "--" in Args, Paths after it
+	before, paths := q.Args, []string(nil)
+	for i, arg := range q.Args {
+		if arg == "--" {
+			before, paths = q.Args[:i], q.Args[i+1:]
+			break
+		}
+	}
+	args = append(args, before...)
+	args = append(args, q.Range...)
+	paths = append(append([]string(nil), paths...), q.Paths...)
+	if len(paths) > 0 {
+		args = append(append(args, "--"), paths...)
+	}
+	return RevisionArgs(args)
+}
+
+// git runs git in q's repository and returns its output
+func (q Query) git(args ...string) ([]byte, error) {
+	command := args[0]
+	if q.Dir != "" {
+		args = append([]string{"-C", q.Dir}, args...)
+	}
+	out, err := exec.Command("git", args...).Output()
+	if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("git %s: %s", command, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// RevisionArgs returns args, with HEAD added if no revision was given
+// (git shortlog reads from stdin instead of walking history otherwise)
+func RevisionArgs(args []string) []string {
+	// Check if args contains a revision (something not starting with - and not after --)
+	// and find where to insert HEAD if needed
+	hasRevision := false
+	doubleDashIndex := -1
+	for i, arg := range args {
+		if arg == "--" {
+			doubleDashIndex = i
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			hasRevision = true
+		}
+	}
+
+	if hasRevision {
+		return args
+	}
+
+	// Build args with HEAD in correct position (before -- if present)
+	var result []string
+	if doubleDashIndex >= 0 {
+		// Insert HEAD before --
+		result = append(result, args[:doubleDashIndex]...)
+		result = append(result, "HEAD")
+		result = append(result, args[doubleDashIndex:]...)
+	} else {
+		// No --, just append everything then HEAD
+		result = append(result, args...)
+		result = append(result, "HEAD")
+	}
+	return result
+}
+
+var emailRe = regexp.MustCompile(`<[^>]+>$`)
+
+// ParseShortlog parses git shortlog -n -s -e output into entries
+func ParseShortlog(output string) []Entry {
+	var entries []Entry
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		// Format: "  123\tName <email>"
+		parts := strings.SplitN(line, "\t", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		count, _ := strconv.Atoi(strings.TrimSpace(parts[0]))
+		rest := strings.TrimSpace(parts[1])
+
+		email := emailRe.FindString(rest)
+		name := strings.TrimSpace(strings.TrimSuffix(rest, email))
+
+		entries = append(entries, Entry{Name: name, Email: email, Count: count})
+	}
+	return entries
+}
+
+// Format renders entries as aligned plain-text lines: count, name, email
+func Format(entries []Entry) string {
+	maxCount, maxName := 0, 0
+	for _, e := range entries {
+		maxCount = max(maxCount, len(strconv.Itoa(e.Count)))
+		maxName = max(maxName, len(e.Name))
+	}
+
+	var b strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&b, "%*d  %-*s  %s\n", maxCount, e.Count, maxName, e.Name, e.Email)
+	}
+	return b.String()
+}
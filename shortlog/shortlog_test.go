@@ -0,0 +1,146 @@
+package shortlog
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testRepo makes a repository with two commits by Jane and one by John,
+// all committed by Release Bot
+func testRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_COMMITTER_NAME=Release Bot", "GIT_COMMITTER_EMAIL=bot@example.com")
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	commit := func(name, email, file, message string) {
+		t.Helper()
+		path := filepath.Join(dir, file)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(message+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		git("add", file)
+		git("-c", "user.name="+name, "-c", "user.email="+email, "commit", "-q", "-m", message)
+	}
+
+	git("init", "-q", "-b", "main")
+	commit("Jane Smith", "jane@example.com", "main.go", "Initial commit")
+	git("tag", "v1.0")
+	commit("Jane Smith", "jane@example.com", "main.go", "Add feature")
+	commit("John Doe", "john@example.com", "docs/guide.md", "Write docs")
+	return dir
+}
+
+func TestParseShortlog(t *testing.T) {
+	out := "   100\tJohn Doe <john@example.com>\n" +
+		"    50\tJane Smith <jane@example.com>\n" +
+		"\n" +
+		"     1\tNo Email\n" +
+		"garbage\n"
+
+	want := []Entry{
+		{"John Doe", "<john@example.com>", 100},
+		{"Jane Smith", "<jane@example.com>", 50},
+		{"No Email", "", 1},
+	}
+	if got := ParseShortlog(out); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseShortlog = %+v, want %+v", got, want)
+	}
+	if got := ParseShortlog(""); got != nil {
+		t.Errorf("ParseShortlog(\"\") = %+v, want nil", got)
+	}
+}
+
+func TestRevisionArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, []string{"HEAD"}},
+		{[]string{"--since=1 week ago"}, []string{"--since=1 week ago", "HEAD"}},
+		{[]string{"HEAD~10..HEAD"}, []string{"HEAD~10..HEAD"}},
+		{[]string{"--", "src/"}, []string{"HEAD", "--", "src/"}},
+		{[]string{"origin..HEAD", "--", "src/"}, []string{"origin..HEAD", "--", "src/"}},
+	}
+	for _, tt := range tests {
+		if got := RevisionArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RevisionArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestQueryLogArgs(t *testing.T) {
+	tests := []struct {
+		q    Query
+		want string
+	}{
+		{Query{}, "HEAD"},
+		{Query{Since: "2024-01-01", Until: "2024-07-01"}, "--since=2024-01-01 --until=2024-07-01 HEAD"},
+		{Query{Range: []string{"v1..v2"}, Paths: []string{"src/"}}, "v1..v2 -- src/"},
+		// Range and Paths combine with revisions and paths already in Args
+		{Query{Range: []string{"v1..v2"}, Paths: []string{"docs/"}, Args: []string{"--no-merges", "--", "src/"}},
+			"--no-merges v1..v2 -- src/ docs/"},
+		{Query{Args: []string{"-w", "--", "src/"}}, "-w HEAD -- src/"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(tt.q.logArgs(), " "); got != tt.want {
+			t.Errorf("%+v: logArgs = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestQueryRun(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := testRepo(t)
+
+	tests := []struct {
+		q    Query
+		want []Entry
+	}{
+		{Query{Dir: dir}, []Entry{{"Jane Smith", "<jane@example.com>", 2}, {"John Doe", "<john@example.com>", 1}}},
+		{Query{Dir: dir, Range: []string{"v1.0..main"}}, []Entry{{"Jane Smith", "<jane@example.com>", 1}, {"John Doe", "<john@example.com>", 1}}},
+		{Query{Dir: dir, Paths: []string{"docs/"}}, []Entry{{"John Doe", "<john@example.com>", 1}}},
+		{Query{Dir: dir, Group: ByCommitter}, []Entry{{"Release Bot", "<bot@example.com>", 3}}},
+	}
+	for _, tt := range tests {
+		r, err := tt.q.Run()
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.q, err)
+		}
+		if !reflect.DeepEqual(r.Entries, tt.want) {
+			t.Errorf("%+v: entries = %+v, want %+v", tt.q, r.Entries, tt.want)
+		}
+		total := 0
+		for _, e := range tt.want {
+			total += e.Count
+		}
+		if r.Commits != total {
+			t.Errorf("%+v: commits = %d, want %d", tt.q, r.Commits, total)
+		}
+	}
+
+	if _, err := (Query{Dir: dir, Range: []string{"no-such-tag"}}).Run(); err == nil || !strings.Contains(err.Error(), "git shortlog") {
+		t.Errorf("expected a git shortlog error for a bad revision, got %v", err)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	got := Format([]Entry{{"Jane Smith", "<jane@example.com>", 120}, {"Jo", "<jo@example.com>", 7}})
+	want := "120  Jane Smith  <jane@example.com>\n" +
+		"  7  Jo          <jo@example.com>\n"
+	if got != want {
+		t.Errorf("Format =\n%s\nwant\n%s", got, want)
+	}
+}
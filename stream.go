@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// How often partial author counts are pushed into fzf while streaming
+const streamInterval = 250 * time.Millisecond
+
+// shortlogOnlyFlags are git shortlog options that git log can't reproduce;
+// if any of them is given, the list is generated by git shortlog in one go
+var shortlogOnlyFlags = []string{
+	"-c", "--committer", "-w", "--group", "--format",
+	"-n", "--numbered", "-s", "--summary", "-e", "--email",
+}
+
+// canStream reports whether the author list can be streamed for gitArgs
+func canStream() bool {
+	if noStream {
+		return false
+	}
+	for _, arg := range gitArgs {
+		if arg == "--" {
+			break
+		}
+		if shortlogOnly(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// shortlogOnly reports whether arg is one of shortlogOnlyFlags
+func shortlogOnly(arg string) bool {
+	for _, flag := range shortlogOnlyFlags {
+		if arg == flag || strings.HasPrefix(arg, flag+"=") ||
+			(flag == "-w" && strings.HasPrefix(arg, "-w")) {
+			return true
+		}
+	}
+	return false
+}
+
+// historyArgs returns args without the shortlog-only options, which git log
+// would take for its own (-c, -n) or reject, for walks that read commits
+// with git log instead of counting them with git shortlog
+func historyArgs(args []string) []string {
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(result, args[i:]...)
+		}
+		if shortlogOnly(arg) {
+			// --group and --format can take their value as the next argument
+			if (arg == "--group" || arg == "--format") && i+1 < len(args) {
+				i++
+			}
+			continue
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// shortlogStream incrementally counts commits per author from a git log walk
+type shortlogStream struct {
+	mu      sync.Mutex
+	counts  map[string]*shortlogEntry
+	static  []shortlogEntry // Fixed list, for output generated by git shortlog
+	commits int
+	version int   // Bumped on every change, so the feeder knows what's new
+	done    bool  // Set once git log has finished
+	err     error // Why git log failed, if it did
+	settled bool  // Set once logins for the final list have been resolved
+
+	proc     *os.Process
+	quit     chan struct{}
+	stopOnce sync.Once
+}
+
+// newStaticStream wraps an already complete list (or the error that git
+// shortlog failed with), so that it still gets updated in fzf once the
+// authors' GitHub logins have been resolved
+func newStaticStream(entries []shortlogEntry, err error) *shortlogStream {
+	s := &shortlogStream{
+		static: entries,
+		done:   true,
+		err:    err,
+		quit:   make(chan struct{}),
+	}
+	go s.resolveLogins()
+	return s
+}
+
+// startShortlogStream starts git log for the given date filter and counts
+// its output in the background
+func startShortlogStream(sinceDate string) (*shortlogStream, error) {
+	args := []string{"log", "--format=%aN%x09%aE"}
+	if sinceDate != "" {
+		args = append(args, "--since="+sinceDate)
+	}
+	args = append(args, revisionArgs()...)
+
+	cmd := gitCommand(args...)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	s := &shortlogStream{
+		counts: make(map[string]*shortlogEntry),
+		quit:   make(chan struct{}),
+	}
+	s.proc = cmd.Process
+	go func() {
+		s.count(stdout)
+		err := cmd.Wait()
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("git log: %s", strings.TrimSpace(stderr.String()))
+		}
+		s.finish(err)
+	}()
+	return s, nil
+}
+
+// consume reads "name<TAB>email" lines and adds them to the counts, until
+// the end of r ends the list
+func (s *shortlogStream) consume(r io.Reader) {
+	s.count(r)
+	s.finish(nil)
+}
+
+// count reads "name<TAB>email" lines and adds them to the counts
+func (s *shortlogStream) count(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		name, email, _ := strings.Cut(scanner.Text(), "\t")
+		email = "<" + email + ">"
+		key := name + " " + email
+
+		s.mu.Lock()
+		e, ok := s.counts[key]
+		if !ok {
+			e = &shortlogEntry{name: name, email: email}
+			s.counts[key] = e
+		}
+		e.count++
+		s.commits++
+		s.version++
+		s.mu.Unlock()
+	}
+}
+
+// finish marks the list as complete, or as failed with err
+func (s *shortlogStream) finish(err error) {
+	s.mu.Lock()
+	s.done = true
+	s.err = err
+	s.version++
+	s.mu.Unlock()
+
+	s.resolveLogins()
+}
+
+// resolveLogins fills the login cache for the final list, then marks the
+// stream as settled (bumping the version if any logins were resolved)
+func (s *shortlogStream) resolveLogins() {
+	var emails []string
+	select {
+	case <-s.quit:
+		// fzf is gone; don't go to the network for nothing
+	default:
+		if logins != nil && orgAndRepo != "" && forgeKind == forgeGitHub {
+			entries, _, _, _ := s.snapshot()
+			for _, e := range entries {
+				if _, ok := logins.lookup(e.email); !ok {
+					emails = append(emails, e.email)
+				}
+			}
+		}
+	}
+	if len(emails) > 0 {
+		resolveLogins(emails)
+	}
+
+	s.mu.Lock()
+	s.settled = true
+	if len(emails) > 0 {
+		s.version++
+	}
+	s.mu.Unlock()
+}
+
+// snapshot returns the current counts in git shortlog -n order
+func (s *shortlogStream) snapshot() (entries []shortlogEntry, commits, version int, done bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.static != nil {
+		return s.static, s.commits, s.version, s.done
+	}
+	for _, e := range s.counts {
+		entries = append(entries, *e)
+	}
+	sortEntries(entries)
+	return entries, s.commits, s.version, s.done
+}
+
+// failed returns the error that git failed with, if it did
+func (s *shortlogStream) failed() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// live reports whether the list is still being counted
+func (s *shortlogStream) live() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.done
+}
+
+// sortEntries orders entries by count (descending), then name, like git shortlog -n
+func sortEntries(entries []shortlogEntry) {
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		if entries[i].name != entries[j].name {
+			return entries[i].name < entries[j].name
+		}
+		return entries[i].email < entries[j].email
+	})
+}
+
+// feed pushes snapshots into the fzf instance listening on addr, until the
+// list has settled and its final state has been delivered, or stop is called.
+// If fzf already has the list (on stdin, or reloaded by a date filter), sent
+// is its version, and only later changes are pushed; otherwise it's -1. If
+// restore is set, the fzf actions it returns for the complete list are run
+// after fzf has loaded that.
+func (s *shortlogStream) feed(addr, header string, sent int, restore func([]shortlogEntry) []string) {
+	// The list file goes next to the control socket, so it's removed on
+	// exit even if the feeder is still waiting for fzf then
+	dir := controlDir
+	if dir == "" {
+		var err error
+		if dir, err = os.MkdirTemp("", "gh-shortlog-stream-*"); err != nil {
+			return
+		}
+		defer os.RemoveAll(dir)
+	}
+	listPath := filepath.Join(dir, "list")
+
+	ticker := time.NewTicker(streamInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.quit:
+			return
+		case <-ticker.C:
+		}
+
+		// Check settled first: anything it covers is then in the snapshot
+		s.mu.Lock()
+		settled := s.settled
+		s.mu.Unlock()
+		entries, _, version, done := s.snapshot()
+		if version == sent {
+			if settled {
+				return
+			}
+			continue
+		}
+
+		// Write atomically, so fzf never reads a partial list
+		if err := writeFileAtomic(listPath, []byte(formatEntries(entries))); err != nil {
+			return
+		}
+
+		action := "reload(cat " + shellQuote(listPath) + ")+change-header:" + statusHeader(header, s)
+		if done && restore != nil {
+			action = "reload-sync(cat " + shellQuote(listPath) + ")+" + strings.Join(restore(entries), "+") +
+				"+change-header:" + statusHeader(header, s)
+		}
+
+		// fzf may not be listening yet; if so, just retry on the next tick
+		resp, err := http.Post("http://"+addr, "text/plain", strings.NewReader(action))
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+
+		sent = version
+		if done {
+			restore = nil
+		}
+		if done && settled {
+			// fzf may still be reading the list file, so keep it until fzf exits
+			<-s.quit
+			return
+		}
+	}
+}
+
+// stop ends the git log walk (if still running) and the feeder; it can be
+// called more than once
+func (s *shortlogStream) stop() {
+	s.stopOnce.Do(func() {
+		close(s.quit)
+		if s.proc != nil {
+			s.proc.Kill()
+		}
+	})
+}
+
+// freeLocalAddr returns a localhost address with a currently unused port,
+// for fzf to listen on
+func freeLocalAddr() (string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer l.Close()
+	return fmt.Sprintf("localhost:%d", l.Addr().(*net.TCPAddr).Port), nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShortlogStreamConsume(t *testing.T) {
+	s := &shortlogStream{counts: make(map[string]*shortlogEntry)}
+	s.consume(strings.NewReader("Jane Smith\tjane@example.com\n" +
+		"John Doe\tjohn@example.com\n" +
+		"Jane Smith\tjane@example.com\n" +
+		"Bob Wilson\tbob@example.com\n"))
+
+	entries, commits, _, done := s.snapshot()
+	if !done {
+		t.Error("expected stream to be done after consuming all input")
+	}
+	if commits != 4 {
+		t.Errorf("commits = %d, want 4", commits)
+	}
+
+	want := []shortlogEntry{
+		{2, "Jane Smith", "<jane@example.com>"},
+		{1, "Bob Wilson", "<bob@example.com>"},
+		{1, "John Doe", "<john@example.com>"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e != want[i] {
+			t.Errorf("entries[%d] = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestCanStream(t *testing.T) {
+	oldGitArgs := gitArgs
+	oldNoStream := noStream
+	defer func() {
+		gitArgs = oldGitArgs
+		noStream = oldNoStream
+	}()
+
+	tests := []struct {
+		name     string
+		args     []string
+		noStream bool
+		want     bool
+	}{
+		{"no args", nil, false, true},
+		{"log options", []string{"--since=1 week ago", "--no-merges"}, false, true},
+		{"revision and path", []string{"v1.0..v2.0", "--", "src/"}, false, true},
+		{"committer grouping", []string{"-c"}, false, false},
+		{"group option", []string{"--group=trailer:co-authored-by"}, false, false},
+		{"wrapping", []string{"-w76,4,8"}, false, false},
+		{"shortlog flag as path", []string{"--", "-c"}, false, true},
+		{"disabled", nil, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitArgs = tt.args
+			noStream = tt.noStream
+			if got := canStream(); got != tt.want {
+				t.Errorf("canStream() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistoryArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{nil, nil},
+		{[]string{"-s", "-n", "-e", "--since=2024-01-01", "main"}, []string{"--since=2024-01-01", "main"}},
+		{[]string{"-c", "--no-merges", "-w76,4,8"}, []string{"--no-merges"}},
+		{[]string{"--group", "trailer:co-authored-by", "--format=%s", "v1..v2"}, []string{"v1..v2"}},
+		{[]string{"--summary", "--", "-s", "src/"}, []string{"--", "-s", "src/"}},
+	}
+	for _, tt := range tests {
+		if got := historyArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("historyArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestShortlogStreamFeed(t *testing.T) {
+	// Stand-in for fzf's --listen server
+	actions := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		actions <- string(body)
+	}))
+	defer server.Close()
+
+	s := &shortlogStream{counts: make(map[string]*shortlogEntry), quit: make(chan struct{})}
+	s.consume(strings.NewReader("John Doe\tjohn@example.com\n"))
+
+	go s.feed(strings.TrimPrefix(server.URL, "http://"), "HEADER", -1, nil)
+	action := <-actions
+	close(s.quit)
+
+	if !strings.HasPrefix(action, "reload(cat ") {
+		t.Errorf("expected reload action, got %q", action)
+	}
+	if !strings.HasSuffix(action, "+change-header:HEADER") {
+		t.Errorf("expected final header without progress, got %q", action)
+	}
+
+	// A date filter's list restores the cursor once it's complete
+	s = &shortlogStream{counts: make(map[string]*shortlogEntry), quit: make(chan struct{})}
+	s.consume(strings.NewReader("John Doe\tjohn@example.com\n"))
+	restore := func(entries []shortlogEntry) []string { return []string{"pos(1)"} }
+	go s.feed(strings.TrimPrefix(server.URL, "http://"), "HEADER", -1, restore)
+	action = <-actions
+	close(s.quit)
+
+	if !strings.HasPrefix(action, "reload-sync(cat ") || !strings.Contains(action, ")+pos(1)+change-header:") {
+		t.Errorf("expected reload-sync with restore actions, got %q", action)
+	}
+}
+
+func TestShortlogStreamError(t *testing.T) {
+	dir, _ := testRepo(t)
+	oldWorkDir, oldGitArgs := workDir, gitArgs
+	defer func() { workDir, gitArgs = oldWorkDir, oldGitArgs }()
+	workDir = dir
+	gitArgs = []string{"no-such-revision"}
+
+	s, err := startShortlogStream("")
+	if err != nil {
+		t.Fatalf("startShortlogStream: %v", err)
+	}
+	defer s.stop()
+	for s.live() {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := s.failed(); err == nil || !strings.Contains(err.Error(), "no-such-revision") {
+		t.Errorf("failed() = %v, want git's error about the revision", err)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Options for --by-tag mode
+var (
+	byTag      bool
+	tagPattern = "*" // Glob for tag names, as for git tag --list
+)
+
+// Release tags: an optional v, then up to three numbers, and an optional
+// pre-release suffix (e.g. v1.2, 1.2.3, v2.0.0-rc.1)
+var semverRe = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?$`)
+
+// release is the interval of history from the previous release tag to tag
+type release struct {
+	tag     string
+	date    string // Tag date (YYYY-MM-DD)
+	rng     string // Revision range, e.g. "v1.0.0..v1.1.0", or just the tag for the first
+	version [3]int
+	pre     string // Pre-release suffix, if any
+}
+
+// runByTag lists the releases, newest first, and opens the usual author
+// list for the one chosen, coming back to the releases after it
+func runByTag() {
+	releases, err := listReleases(tagPattern)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error listing tags: %v\n", err)
+		exit(1)
+	}
+	if len(releases) == 0 {
+		fmt.Fprintf(os.Stderr, "No release tags match %q\n", tagPattern)
+		exit(1)
+	}
+
+	baseArgs := gitArgs
+	for {
+		rng, ok := pickRelease(releases)
+		if !ok {
+			return
+		}
+		gitArgs = append([]string{rng}, baseArgs...)
+		runInteractive()
+		gitArgs = baseArgs
+	}
+}
+
+// listReleases returns the tags matching pattern that look like version
+// numbers, in version order, each with the range since the one before it;
+// commits since the last tag come last, as "HEAD"
+func listReleases(pattern string) ([]release, error) {
+	out, err := gitCommand("for-each-ref", "--format=%(refname:short)%09%(creatordate:short)", "refs/tags/"+pattern).Output()
+	if err != nil {
+		return nil, err
+	}
+	releases := parseReleases(string(out))
+
+	if len(releases) > 0 {
+		last := releases[len(releases)-1].tag
+		if n, err := gitCommand("rev-list", "--count", last+"..HEAD").Output(); err == nil && strings.TrimSpace(string(n)) != "0" {
+			releases = append(releases, release{tag: "HEAD", date: "unreleased", rng: last + "..HEAD"})
+		}
+	}
+	return releases, nil
+}
+
+// parseReleases parses "tag<TAB>date" lines, keeping the version-like tags
+// and sorting them by version
+func parseReleases(out string) []release {
+	var releases []release
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		tag, date, _ := strings.Cut(line, "\t")
+		m := semverRe.FindStringSubmatch(tag)
+		if m == nil {
+			continue
+		}
+		r := release{tag: tag, date: date, pre: m[4]}
+		for i := range r.version {
+			r.version[i], _ = strconv.Atoi(m[i+1])
+		}
+		releases = append(releases, r)
+	}
+
+	sort.SliceStable(releases, func(i, j int) bool {
+		return versionLess(releases[i], releases[j])
+	})
+	for i := range releases {
+		releases[i].rng = releases[i].tag
+		if i > 0 {
+			releases[i].rng = releases[i-1].tag + ".." + releases[i].tag
+		}
+	}
+	return releases
+}
+
+// versionLess orders releases by version number; a pre-release comes
+// before the release itself
+func versionLess(a, b release) bool {
+	if a.version != b.version {
+		for i := range a.version {
+			if a.version[i] != b.version[i] {
+				return a.version[i] < b.version[i]
+			}
+		}
+	}
+	if (a.pre == "") != (b.pre == "") {
+		return a.pre != ""
+	}
+	return preReleaseLess(a.pre, b.pre)
+}
+
+// preReleaseLess orders pre-release suffixes as semver does: identifier by
+// identifier, numbers by value and before anything else, and the others as
+// strings; if one is a prefix of the other, it's the earlier one
+func preReleaseLess(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			return an < bn
+		case aErr == nil || bErr == nil:
+			return aErr == nil
+		default:
+			return as[i] < bs[i]
+		}
+	}
+	return len(as) < len(bs)
+}
+
+// pickRelease shows the releases, newest first, with each one's authors in
+// the preview, and returns the range of the one chosen
+func pickRelease(releases []release) (string, bool) {
+	maxTag := 0
+	for _, r := range releases {
+		maxTag = max(maxTag, len(r.tag))
+	}
+	c := choice{
+		header:  "Releases, newest first  │  Enter: show the release's authors  │  Esc: exit",
+		prompt:  "Release",
+		preview: "_release",
+	}
+	for i := len(releases) - 1; i >= 0; i-- {
+		r := releases[i]
+		c.items = append(c.items, fmt.Sprintf("%s%-*s%s  %s%-10s%s  %s",
+			colorWhite, maxTag, r.tag, colorReset, colorCyan, r.date, colorReset, r.rng))
+		c.keys = append(c.keys, r.rng)
+	}
+
+	i, ok := ui.choose(c)
+	if !ok {
+		return "", false
+	}
+	return c.keys[i], true
+}
+
+// Subcommand: _release
+func runReleaseSubcommand(args []string) {
+	parseArgs(nil) // Load the state from the control server
+
+	if len(args) < 1 {
+		return
+	}
+	logins = loadLoginCache()
+	writeRelease(os.Stdout, args[0])
+}
+
+// writeRelease writes the authors of the release range rng, as shown in
+// the preview of the release list
+func writeRelease(w io.Writer, rng string) {
+	query := gitQuery("")
+	query.Args = append([]string{rng}, gitArgs...)
+	result, err := query.Run()
+	if err != nil {
+		fmt.Fprintf(w, "%s%v%s\n", colorRed, err, colorReset)
+		return
+	}
+	entries := shortlogEntries(result.Entries)
+	commits := 0
+	for _, e := range entries {
+		commits += e.count
+	}
+	fmt.Fprintf(w, "%s%s%s: %d commits by %d authors\n\n", colorBoldCyan, rng, colorReset, commits, len(entries))
+	fmt.Fprint(w, formatEntries(entries))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseReleases(t *testing.T) {
+	out := "v1.10.0\t2024-06-01\n" +
+		"v1.2.0\t2024-02-01\n" +
+		"nightly\t2024-06-02\n" +
+		"v2.0.0-rc.1\t2024-07-01\n" +
+		"v2.0.0\t2024-08-01\n" +
+		"v1.2.0-beta\t2024-01-15\n" +
+		"1.9\t2024-05-01\n"
+
+	releases := parseReleases(out)
+	var tags, ranges []string
+	for _, r := range releases {
+		tags = append(tags, r.tag)
+		ranges = append(ranges, r.rng)
+	}
+	if want := []string{"v1.2.0-beta", "v1.2.0", "1.9", "v1.10.0", "v2.0.0-rc.1", "v2.0.0"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("tags = %v, want %v", tags, want)
+	}
+	if ranges[0] != "v1.2.0-beta" || ranges[3] != "1.9..v1.10.0" || ranges[5] != "v2.0.0-rc.1..v2.0.0" {
+		t.Errorf("unexpected ranges: %v", ranges)
+	}
+	if releases[1].date != "2024-02-01" {
+		t.Errorf("date = %q, want 2024-02-01", releases[1].date)
+	}
+}
+
+func TestListReleases(t *testing.T) {
+	dir, git := testRepo(t)
+	oldWorkDir, oldGitArgs := workDir, gitArgs
+	defer func() { workDir, gitArgs = oldWorkDir, oldGitArgs }()
+	workDir = dir
+	gitArgs = nil
+
+	git("commit", "-q", "--allow-empty", "-m", "First")
+	git("tag", "v1.0.0")
+	git("commit", "-q", "--allow-empty", "-m", "Second")
+	git("tag", "v1.1.0")
+	git("tag", "docs-update")
+	git("commit", "-q", "--allow-empty", "-m", "Third")
+
+	releases, err := listReleases("*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ranges []string
+	for _, r := range releases {
+		ranges = append(ranges, r.rng)
+	}
+	if want := []string{"v1.0.0", "v1.0.0..v1.1.0", "v1.1.0..HEAD"}; !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+
+	// The pattern filters tags before they're paired up into ranges
+	releases, _ = listReleases("v1.1.*")
+	if len(releases) != 2 || releases[0].rng != "v1.1.0" {
+		t.Errorf("with pattern: %+v", releases)
+	}
+}
+
+func TestVersionLess(t *testing.T) {
+	// In order, as semver has them
+	tags := []string{
+		"v1.0.0-alpha", "v1.0.0-alpha.1", "v1.0.0-alpha.beta", "v1.0.0-beta",
+		"v1.0.0-beta.2", "v1.0.0-beta.11", "v1.0.0-rc.1", "v1.0.0-rc.2", "v1.0.0-rc.10", "v1.0.0",
+	}
+	var out string
+	for i := len(tags) - 1; i >= 0; i-- {
+		out += tags[i] + "\t2024-01-01\n"
+	}
+	var got []string
+	for _, r := range parseReleases(out) {
+		got = append(got, r.tag)
+	}
+	if !reflect.DeepEqual(got, tags) {
+		t.Errorf("order = %v, want %v", got, tags)
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// terminal is the controlling terminal, in raw mode on the alternate
+// screen, for the builtin frontend
+type terminal struct {
+	tty    *os.File
+	saved  string // stty settings to restore
+	resize chan os.Signal
+	width  int
+	height int
+	buf    []byte // Input read but not yet returned as keys
+}
+
+// openTerminal switches the controlling terminal to raw mode and the
+// alternate screen. Reads time out after a tenth of a second, so the caller
+// can update the screen while waiting for keys. It needs /dev/tty and stty,
+// so it fails on Windows, where the builtin frontend uses the prompt one.
+func openTerminal() (*terminal, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	t := &terminal{tty: tty, resize: make(chan os.Signal, 1)}
+	out, err := t.stty("-g")
+	if err != nil {
+		tty.Close()
+		return nil, err
+	}
+	t.saved = strings.TrimSpace(out)
+	if err := t.resume(); err != nil {
+		tty.Close()
+		return nil, err
+	}
+	notifyResize(t.resize)
+	return t, nil
+}
+
+// stty runs stty on the terminal
+func (t *terminal) stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = t.tty
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// suspend restores the terminal's normal mode, e.g. to run a pager
+func (t *terminal) suspend() {
+	fmt.Fprint(t.tty, "\033[?25h\033[?1049l")
+	t.stty(t.saved)
+}
+
+// resume switches (back) to raw mode and the alternate screen
+func (t *terminal) resume() error {
+	if _, err := t.stty("raw", "-echo", "min", "0", "time", "1"); err != nil {
+		return err
+	}
+	fmt.Fprint(t.tty, "\033[?1049h\033[?25l")
+	return t.updateSize()
+}
+
+func (t *terminal) close() {
+	stopResize(t.resize)
+	t.suspend()
+	t.tty.Close()
+}
+
+// updateSize reads the terminal's size; it's 80×24 if that fails
+func (t *terminal) updateSize() error {
+	t.width, t.height = 80, 24
+	out, err := t.stty("size")
+	if err != nil {
+		return err
+	}
+	rows, cols, _ := strings.Cut(strings.TrimSpace(out), " ")
+	if h, err := strconv.Atoi(rows); err == nil && h > 0 {
+		t.height = h
+	}
+	if w, err := strconv.Atoi(cols); err == nil && w > 0 {
+		t.width = w
+	}
+	return nil
+}
+
+// resized reports whether the terminal has changed size since last asked
+func (t *terminal) resized() bool {
+	select {
+	case <-t.resize:
+		t.updateSize()
+		return true
+	default:
+		return false
+	}
+}
+
+// readKeys waits up to a tenth of a second for input, and returns the keys
+// read, if any (see parseKeys)
+func (t *terminal) readKeys() ([]string, error) {
+	var b [256]byte
+	n, err := t.tty.Read(b[:])
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	t.buf = append(t.buf, b[:n]...)
+	keys, rest := parseKeys(t.buf)
+	t.buf = rest
+	return keys, nil
+}
+
+// Escape sequences for the keys that have them
+var escapeKeys = map[string]string{
+	"[A": "up", "[B": "down", "OA": "up", "OB": "down",
+	"[5~": "page-up", "[6~": "page-down",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[Z": "shift-tab",
+}
+
+// parseKeys splits raw terminal input into key names, as fzf names them
+// (e.g. "a", "ctrl-t", "alt-p", "enter"), and returns any incomplete UTF-8
+// sequence at the end, to be completed by the next read
+func parseKeys(b []byte) (keys []string, rest []byte) {
+	for len(b) > 0 {
+		c := b[0]
+		switch {
+		case c == 0x1b:
+			if len(b) == 1 {
+				keys = append(keys, "esc")
+				b = b[1:]
+				continue
+			}
+			// CSI or SS3 sequence: up to and including its final letter or ~
+			if b[1] == '[' || b[1] == 'O' {
+				end := 2
+				for end < len(b) && (b[end] < 0x40 || b[end] > 0x7e) {
+					end++
+				}
+				if end < len(b) && (b[1] == '[' || end == 2) {
+					if key, ok := escapeKeys[string(b[1:end+1])]; ok {
+						keys = append(keys, key)
+					}
+					b = b[end+1:]
+					continue
+				}
+			}
+			if b[1] >= 0x20 && b[1] < 0x7f {
+				keys = append(keys, "alt-"+string(b[1]))
+			} else {
+				keys = append(keys, "esc")
+			}
+			b = b[2:]
+		case c == '\r':
+			keys = append(keys, "enter")
+			b = b[1:]
+		case c == '\t':
+			keys = append(keys, "tab")
+			b = b[1:]
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+			b = b[1:]
+		case c < 0x20:
+			keys = append(keys, "ctrl-"+string(rune('a'+c-1)))
+			b = b[1:]
+		default:
+			if !utf8.FullRune(b) {
+				return keys, b
+			}
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+		}
+	}
+	return keys, nil
+}
+
+// runeWidth is the number of terminal columns r takes up: 2 for wide
+// (mostly East Asian) characters and emoji, 0 for combining marks
+func runeWidth(r rune) int {
+	switch {
+	case r >= 0x300 && r <= 0x36f, r == 0x200b, r >= 0xfe00 && r <= 0xfe0f:
+		return 0
+	case r >= 0x1100 && r <= 0x115f, r >= 0x2e80 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3, r >= 0xf900 && r <= 0xfaff,
+		r >= 0xfe30 && r <= 0xfe4f, r >= 0xff00 && r <= 0xff60,
+		r >= 0xffe0 && r <= 0xffe6, r >= 0x1f300 && r <= 0x1faff,
+		r >= 0x20000 && r <= 0x3fffd:
+		return 2
+	}
+	return 1
+}
+
+// fitLine cuts the (possibly colorized) line s to width columns, padding it
+// with spaces if it's shorter; color codes are kept, and reset at the end
+func fitLine(s string, width int) string {
+	var b strings.Builder
+	used := 0
+	for i := 0; i < len(s); {
+		if s[i] == 0x1b {
+			if loc := ansiRe.FindStringIndex(s[i:]); loc != nil && loc[0] == 0 {
+				b.WriteString(s[i : i+loc[1]])
+				i += loc[1]
+				continue
+			}
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		if r == '\t' {
+			r = ' '
+		}
+		if r < 0x20 {
+			continue
+		}
+		w := runeWidth(r)
+		if used+w > width {
+			break
+		}
+		b.WriteRune(r)
+		used += w
+	}
+	b.WriteString(colorReset)
+	b.WriteString(strings.Repeat(" ", width-used))
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		input string
+		keys  []string
+		rest  string
+	}{
+		{"ab", []string{"a", "b"}, ""},
+		{"\r\t\x7f", []string{"enter", "tab", "backspace"}, ""},
+		{"\x14\x11\x0e", []string{"ctrl-t", "ctrl-q", "ctrl-n"}, ""},
+		{"\x1b", []string{"esc"}, ""},
+		{"\x1bp\x1bh", []string{"alt-p", "alt-h"}, ""},
+		{"\x1b[A\x1b[B\x1bOA", []string{"up", "down", "up"}, ""},
+		{"\x1b[5~\x1b[6~", []string{"page-up", "page-down"}, ""},
+		{"\x1b[1;5A", nil, ""}, // Unknown sequences are skipped
+		{"é\xe2\x80", []string{"é"}, "\xe2\x80"},
+	}
+	for _, tt := range tests {
+		keys, rest := parseKeys([]byte(tt.input))
+		if !reflect.DeepEqual(keys, tt.keys) || string(rest) != tt.rest {
+			t.Errorf("parseKeys(%q) = %q, %q; want %q, %q", tt.input, keys, rest, tt.keys, tt.rest)
+		}
+	}
+}
+
+func TestFitLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		width int
+		want  string
+	}{
+		{"abc", 5, "abc" + colorReset + "  "},
+		{"abcdef", 4, "abcd" + colorReset},
+		{colorGreen + "abc" + colorReset + "def", 4, colorGreen + "abc" + colorReset + "d" + colorReset},
+		{"日本語", 5, "日本" + colorReset + " "}, // Wide characters take two columns
+		{"a\tb", 3, "a b" + colorReset},
+	}
+	for _, tt := range tests {
+		if got := fitLine(tt.line, tt.width); got != tt.want {
+			t.Errorf("fitLine(%q, %d) = %q, want %q", tt.line, tt.width, got, tt.want)
+		}
+	}
+}